@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"procmate/pkg/config"
+	"procmate/pkg/control"
+	"procmate/pkg/process"
+)
+
+// startTargets 解析 targets，按依赖关系分层并行启动 (包括依赖)，启动失败的进程会被停止。
+// forceFreePort 为 true 时，端口被之前由 procmate 启动的残留进程占用会先停止该进程。
+// 返回每个进程的启动结果，以及无法识别的名称。没有可启动的进程时结果为空。
+func startTargets(targets []string, forceFreePort bool) ([]process.StartupResult, []string, error) {
+	allEnabledProcesses, requestedProcesses, invalidNames := resolveProcesses(targets)
+	if len(requestedProcesses) == 0 {
+		return nil, invalidNames, nil
+	}
+
+	lock, err := process.LockGlobal(false)
+	if err != nil {
+		return nil, invalidNames, err
+	}
+	defer lock.Unlock()
+
+	// 定时任务只在被显式指定时立即运行一次，不随 all / @group 启动
+	var services []config.Process
+	var results []process.StartupResult
+	for _, proc := range requestedProcesses {
+		if proc.Schedule == "" {
+			services = append(services, proc)
+			continue
+		}
+		if !namedExplicitly(proc, targets) {
+			continue
+		}
+		begin := time.Now()
+		err := process.RunJob(proc)
+		if err != nil {
+			fmt.Printf("❌ 定时任务 %s 运行失败: %v\n", proc.Name, err)
+		}
+		results = append(results, process.StartupResult{
+			Process:  proc,
+			Success:  err == nil,
+			Error:    err,
+			Duration: time.Since(begin),
+		})
+	}
+	if len(services) == 0 {
+		return results, invalidNames, nil
+	}
+
+	// 获取分层执行计划（支持并行启动）
+	executionLayers, err := process.GetExecutionLayers(allEnabledProcesses, services)
+	if err != nil {
+		return nil, invalidNames, fmt.Errorf("无法确定启动计划: %w", err)
+	}
+
+	// 使用智能失败处理的并行启动管理器执行启动
+	options := process.GetSmartParallelStartOptions()
+	options.ForceFreePort = forceFreePort
+	manager := process.NewParallelStartManager(options)
+	layerResults, err := manager.StartProcessesInLayers(executionLayers, context.Background())
+	if err != nil {
+		return nil, invalidNames, fmt.Errorf("并行启动失败: %w", err)
+	}
+
+	for _, layerResult := range layerResults {
+		for _, result := range layerResult.Results {
+			if !result.Success && !result.IsSkipped {
+				fmt.Printf("❌ 进程 %s 启动失败: %v\n", result.Process.Name, result.Error)
+				// 进程正被其他 procmate 操作时不能停止它
+				var locked *process.LockedError
+				if !errors.As(result.Error, &locked) {
+					// TODO 这儿应该是并行的去停止
+					process.Stop(result.Process)
+				}
+			}
+			results = append(results, result)
+		}
+	}
+	return results, invalidNames, nil
+}
+
+// namedExplicitly 判断进程是否在 targets 中按名称 (或多实例的基础名称) 被直接指定。
+func namedExplicitly(proc config.Process, targets []string) bool {
+	for _, t := range targets {
+		if t == proc.Name || (proc.InstanceOf != "" && t == proc.InstanceOf) {
+			return true
+		}
+	}
+	return false
+}
+
+// stopTargets 解析 targets，按依赖关系分层并行停止。noDeps 为 true 时只并行停止 targets 本身。
+// 返回每个进程的停止结果，以及无法识别的名称。没有可停止的进程时结果为空。
+func stopTargets(targets []string, noDeps bool) ([]process.StopResult, []string, error) {
+	allEnabledProcesses, requestedProcesses, invalidNames := resolveProcesses(targets)
+	if len(requestedProcesses) == 0 {
+		return nil, invalidNames, nil
+	}
+
+	lock, err := process.LockGlobal(false)
+	if err != nil {
+		return nil, invalidNames, err
+	}
+	defer lock.Unlock()
+
+	// 获取分层执行计划（支持并行停止）
+	executionLayers := [][]config.Process{requestedProcesses}
+	if !noDeps {
+		executionLayers, err = process.GetExecutionLayers(allEnabledProcesses, requestedProcesses)
+		if err != nil {
+			return nil, invalidNames, fmt.Errorf("无法确定停止计划: %w", err)
+		}
+	}
+
+	// 使用并行停止管理器执行停止
+	manager := process.NewParallelStopManager(process.GetDefaultParallelStopOptions())
+	layerResults, err := manager.StopProcessesInLayers(executionLayers, context.Background())
+	if err != nil {
+		return nil, invalidNames, fmt.Errorf("并行停止失败: %w", err)
+	}
+
+	var results []process.StopResult
+	for _, layerResult := range layerResults {
+		for _, result := range layerResult.Results {
+			if !result.Success && result.WasRunning {
+				fmt.Printf("❌ 进程 %s 停止失败: %v\n", result.Process.Name, result.Error)
+			}
+			results = append(results, result)
+		}
+	}
+	return results, invalidNames, nil
+}
+
+// markStopped 记录被停止的进程，watch 不会自动拉起它们，直到再次启动。
+func markStopped(results []process.StopResult) {
+	for _, r := range results {
+		if err := process.MarkStopped(r.Process.Name); err != nil {
+			fmt.Printf("⚠️ 记录进程 '%s' 的守护状态失败: %v\n", r.Process.Name, err)
+		}
+	}
+}
+
+// clearStopped 清除启动成功的进程此前被 stop 留下的标记，watch 恢复守护它们。
+func clearStopped(results []process.StartupResult) {
+	for _, r := range results {
+		if !r.Success {
+			continue
+		}
+		if err := process.ClearStopped(r.Process.Name); err != nil {
+			fmt.Printf("⚠️ 记录进程 '%s' 的守护状态失败: %v\n", r.Process.Name, err)
+		}
+	}
+}
+
+// connectDaemon 在 watch 守护进程运行时返回其控制接口的客户端，未运行时返回 nil。
+// 守护进程在运行但无法连接时返回错误，此时不能绕过守护进程自行操作进程。
+func connectDaemon() (*control.Client, error) {
+	return control.Connect(config.RuntimeDir())
+}
+
+// printActionResponse 打印守护进程返回的操作结果。
+func printActionResponse(resp *control.ActionResponse, verb string) {
+	if len(resp.Invalid) > 0 {
+		fmt.Printf("⚠️ 警告：以下服务名称无效或未启用: %s\n", strings.Join(resp.Invalid, ", "))
+	}
+	if len(resp.Results) == 0 {
+		fmt.Printf("🤔 没有指定要%s的进程，或者没有已启用的进程。\n", verb)
+		return
+	}
+	for _, r := range resp.Results {
+		switch {
+		case r.Success && r.Skipped:
+			fmt.Printf("🟡 进程 '%s' 无需%s\n", r.Process, verb)
+		case r.Success:
+			fmt.Printf("✅ 进程 '%s' 已%s (%.1fs)\n", r.Process, verb, r.DurationSec)
+		default:
+			fmt.Printf("❌ 进程 '%s' %s失败: %s\n", r.Process, verb, r.Error)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"procmate/pkg/config"
+	"procmate/pkg/control"
+	"procmate/pkg/process"
+)
+
+// stateMu 保证守护进程的巡检、重新加载配置与控制接口的操作互斥执行。
+// 配置一律通过 config.Current() 读取快照，只读接口不需要持有 stateMu。
+var stateMu sync.Mutex
+
+// daemonBackend 是 watch 守护进程对控制接口的实现。
+type daemonBackend struct{}
+
+// enabledProcesses 返回当前配置中所有已启用的进程。
+func enabledProcesses() []config.Process {
+	var procs []config.Process
+	for _, p := range config.Current().Processes {
+		if p.Enabled {
+			procs = append(procs, p)
+		}
+	}
+	return procs
+}
+
+func (daemonBackend) Processes() ([]process.ProcessInfo, error) {
+	return process.GetProcessInfos(enabledProcesses())
+}
+
+func (daemonBackend) Start(req control.TargetsRequest) (*control.ActionResponse, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	results, invalid, err := startTargets(req.Targets, req.ForceFreePort)
+	if err != nil {
+		return nil, err
+	}
+	clearStopped(results)
+	resp := &control.ActionResponse{Invalid: invalid}
+	for _, r := range results {
+		resp.Results = append(resp.Results, startActionResult(r))
+	}
+	return resp, nil
+}
+
+func (daemonBackend) Stop(req control.TargetsRequest) (*control.ActionResponse, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	results, invalid, err := stopTargets(req.Targets, req.NoDeps)
+	if err != nil {
+		return nil, err
+	}
+	markStopped(results)
+	resp := &control.ActionResponse{Invalid: invalid}
+	for _, r := range results {
+		resp.Results = append(resp.Results, stopActionResult(r))
+	}
+	return resp, nil
+}
+
+func (daemonBackend) Restart(req control.TargetsRequest) (*control.ActionResponse, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	stopResults, invalid, err := stopTargets(req.Targets, false)
+	if err != nil {
+		return nil, err
+	}
+	resp := &control.ActionResponse{Invalid: invalid}
+	for _, r := range stopResults {
+		if !r.Success && r.WasRunning {
+			resp.Results = append(resp.Results, stopActionResult(r))
+		}
+	}
+	if len(resp.Results) > 0 {
+		// 有进程未能停止时不再启动
+		return resp, nil
+	}
+
+	startResults, _, err := startTargets(req.Targets, req.ForceFreePort)
+	if err != nil {
+		return nil, err
+	}
+	clearStopped(startResults)
+	for _, r := range startResults {
+		resp.Results = append(resp.Results, startActionResult(r))
+	}
+	return resp, nil
+}
+
+func (daemonBackend) Reload() (*control.ReloadResponse, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	before := make(map[string]bool)
+	for _, p := range config.Current().Processes {
+		before[p.Name] = true
+	}
+	if err := config.LoadConfig(configPath); err != nil {
+		return nil, fmt.Errorf("重新加载配置文件 %s 失败: %w", configPath, err)
+	}
+
+	resp := &control.ReloadResponse{}
+	for _, p := range config.Current().Processes {
+		if !before[p.Name] {
+			resp.Added = append(resp.Added, p.Name)
+		}
+		delete(before, p.Name)
+	}
+	for name := range before {
+		resp.Removed = append(resp.Removed, name)
+	}
+	fmt.Printf("🔄 已重新加载配置文件 %s，新增 %d 个进程，移除 %d 个进程\n", configPath, len(resp.Added), len(resp.Removed))
+	return resp, nil
+}
+
+func (daemonBackend) Logs(ctx context.Context, targets []string, opts process.TailOptions, fn func(process.LogLine)) error {
+	_, procs, invalid := resolveProcesses(targets)
+	if len(invalid) > 0 {
+		return fmt.Errorf("以下服务名称无效或未启用: %v", invalid)
+	}
+	return process.StreamLogLines(ctx, procs, opts, fn)
+}
+
+// startActionResult 将启动结果转换为控制接口的结果。
+func startActionResult(r process.StartupResult) control.ActionResult {
+	result := control.ActionResult{
+		Process:     r.Process.Name,
+		Success:     r.Success,
+		Skipped:     r.IsSkipped,
+		DurationSec: r.Duration.Seconds(),
+	}
+	if r.Error != nil {
+		result.Error = r.Error.Error()
+	}
+	return result
+}
+
+// stopActionResult 将停止结果转换为控制接口的结果。
+func stopActionResult(r process.StopResult) control.ActionResult {
+	result := control.ActionResult{
+		Process:     r.Process.Name,
+		Success:     r.Success,
+		Skipped:     !r.WasRunning,
+		DurationSec: r.Duration.Seconds(),
+	}
+	if r.Error != nil {
+		result.Error = r.Error.Error()
+	}
+	return result
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"procmate/pkg/process"
+
+	"github.com/spf13/cobra"
+)
+
+// events 命令的参数
+var (
+	eventsFollow bool
+	eventsSince  string
+)
+
+// eventIcons 是各类事件在输出中的图标
+var eventIcons = map[string]string{
+	process.EventSpawn:       "🚀",
+	process.EventReady:       "✅",
+	process.EventUnready:     "🟠",
+	process.EventProbeFailed: "⏱️",
+	process.EventExit:        "💥",
+	process.EventOffline:     "🚨",
+	process.EventRestart:     "🔁",
+	process.EventStop:        "🛑",
+	process.EventKill:        "⚡",
+	process.EventSkip:        "⏭️",
+}
+
+// eventsCmd 定义 events 子命令，用于查询进程生命周期事件
+var eventsCmd = &cobra.Command{
+	Use:   "events [process-name...|@group]",
+	Short: "查看进程的生命周期事件 (启动、就绪、退出、重启等) 📜",
+	Long: `查看记录在 runtime_dir/events.log 中的进程生命周期事件，包括：
+spawn (启动)、ready / unready (就绪状态变化)、probe_failed (就绪检查超时)、
+exit (退出码或信号)、offline (watch 发现进程离线)、restart (自动重启)、
+stop (请求停止)、kill (超时后强制终止) 以及 skip (定时任务因上次运行尚未结束而跳过)。
+
+  procmate events                  # 所有事件
+  procmate events api --since 1h   # api 最近 1 小时的事件
+  procmate events --follow         # 输出历史后继续追踪新事件
+
+进程名不在配置中时 (例如已删除的进程) 按名称精确匹配，事件日志中没有记录的名称和无效的分组会报错。`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter := process.EventFilter{}
+		if eventsSince != "" {
+			since, err := process.ParseSince(eventsSince)
+			if err != nil {
+				return fmt.Errorf("❌ 错误: %w", err)
+			}
+			filter.Since = since
+		}
+		if len(args) > 0 {
+			processes, err := resolveEventTargets(args)
+			if err != nil {
+				return err
+			}
+			filter.Processes = processes
+		}
+
+		count := 0
+		offset, err := process.ReadEvents(filter, func(e process.Event) {
+			printEvent(e)
+			count++
+		})
+		if err != nil {
+			return fmt.Errorf("❌ 读取事件日志失败: %w", err)
+		}
+		if !eventsFollow {
+			if count == 0 {
+				fmt.Println("🤔 没有找到符合条件的事件。")
+			}
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := process.FollowEvents(ctx, offset, filter, printEvent); err != nil {
+			return fmt.Errorf("❌ 追踪事件日志失败: %w", err)
+		}
+		return nil
+	},
+}
+
+// resolveEventTargets 与 start / stop 一样解析进程名和 @分组，返回要筛选的进程名。
+// 不在配置中的进程名只要在事件日志中有记录 (例如已删除的进程) 就按名称精确匹配，
+// 无效的分组和从未出现过的进程名会报错。
+func resolveEventTargets(args []string) (map[string]bool, error) {
+	processes := make(map[string]bool)
+	_, found, invalidNames := resolveProcesses(args)
+	for _, p := range found {
+		processes[p.Name] = true
+	}
+
+	var unknown []string
+	candidates := make(map[string]bool)
+	for _, name := range invalidNames {
+		if strings.HasPrefix(name, "@") {
+			unknown = append(unknown, name)
+		} else {
+			candidates[name] = true
+		}
+	}
+	recorded := make(map[string]bool)
+	if len(candidates) > 0 {
+		_, err := process.ReadEvents(process.EventFilter{Processes: candidates}, func(e process.Event) {
+			recorded[e.Process] = true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("❌ 读取事件日志失败: %w", err)
+		}
+		for _, name := range invalidNames {
+			if candidates[name] && !recorded[name] {
+				unknown = append(unknown, name)
+				candidates[name] = false
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("❌ 以下服务名称或分组无效、未启用，且没有记录过事件: %s", strings.Join(unknown, ", "))
+	}
+	for name := range recorded {
+		processes[name] = true
+	}
+	return processes, nil
+}
+
+// printEvent 以一行文本打印事件。
+func printEvent(e process.Event) {
+	icon := eventIcons[e.Type]
+	if icon == "" {
+		icon = "•"
+	}
+
+	var details []string
+	if e.PID > 0 {
+		details = append(details, fmt.Sprintf("PID %d", e.PID))
+	}
+	if e.Signal != "" {
+		details = append(details, "信号 "+e.Signal)
+	} else if e.Code != nil {
+		details = append(details, fmt.Sprintf("退出码 %d", *e.Code))
+	}
+	if e.Message != "" {
+		details = append(details, e.Message)
+	}
+
+	line := fmt.Sprintf("%s  %s  %s %s  %s",
+		e.Time.Local().Format("2006-01-02 15:04:05"),
+		padRight(e.Process, 16),
+		icon,
+		padRight(e.Type, 12),
+		strings.Join(details, "，"))
+	fmt.Println(strings.TrimRight(line, " "))
+}
+
+func init() {
+	eventsCmd.Flags().BoolVar(&eventsFollow, "follow", false, "输出历史后继续追踪新事件")
+	eventsCmd.Flags().StringVar(&eventsSince, "since", "", "只输出该时间之后的事件，例如 10m、2h、2026-10-01T10:00")
+	rootCmd.AddCommand(eventsCmd)
+}
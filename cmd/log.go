@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"procmate/pkg/config" // 导入配置包
 	"procmate/pkg/process"
@@ -9,33 +11,83 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// logCmd 定义 log 子命令，用于追踪指定进程当天日志
+// log 命令的历史与过滤参数
+var (
+	logLines    int
+	logSince    string
+	logNoFollow bool
+	logGrep     string
+	logLevel    string
+)
+
+// logCmd 定义 log 子命令，用于追踪一个或多个进程当天的日志
 var logCmd = &cobra.Command{
-	Use:   "log [process-name]",
+	Use:   "log [process-name...|@group|all]",
 	Short: "追踪指定进程当天的日志 📃",
-	Long:  "追踪指定进程当天的日志输出，类似 tail -f。",
-	Args:  cobra.ExactArgs(1), // log 命令总是需要一个明确的目标
-	RunE: func(cmd *cobra.Command, args []string) error {
-		processName := args[0]
+	Long: `追踪指定进程当天的日志输出，类似 tail -f。
+
+可以先输出一段历史 (包括已轮转和压缩的日志)，再继续追踪：
+  procmate log api -n 200              # 最近 200 行
+  procmate log api --since 10m         # 最近 10 分钟
+  procmate log api --since 2026-10-01T10:00 --no-follow
+  procmate log api --grep 'timeout|refused' --level warn
 
+指定多个进程、@group 或 all 时，所有日志交织输出，每行带有按进程着色的前缀：
+  procmate log api worker @web -n 50`,
+	Args: cobra.MinimumNArgs(1), // log 命令总是需要一个明确的目标
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// === 查找进程对象 ===
-		var found *config.Process
-		for _, p := range config.Cfg.Processes {
-			if p.Name == processName {
-				temp := p
-				found = &temp
-				break
+		_, found, invalidNames := resolveProcesses(args)
+
+		// 未启用的进程也可能留有日志，按名称精确查找
+		for _, name := range invalidNames {
+			var disabled *config.Process
+			for _, p := range config.Current().Processes {
+				if p.Name == name {
+					temp := p
+					disabled = &temp
+					break
+				}
+			}
+			if disabled == nil {
+				// 返回错误，由 Cobra 的调用者处理打印和退出
+				return fmt.Errorf("❌ 错误: 在配置文件中未找到名为 '%s' 的进程", name)
 			}
+			found = append(found, *disabled)
 		}
 
-		if found == nil {
-			// 返回错误，由 Cobra 的调用者处理打印和退出
-			return fmt.Errorf("❌ 错误: 在配置文件中未找到名为 '%s' 的进程", processName)
+		if len(found) == 0 {
+			fmt.Println("🤔 没有找到要查看日志的进程。")
+			return nil
+		}
+
+		// === 解析历史与过滤参数 ===
+		opts := process.TailOptions{Lines: logLines, Follow: !logNoFollow}
+		if logSince != "" {
+			since, err := process.ParseSince(logSince)
+			if err != nil {
+				return fmt.Errorf("❌ 错误: %w", err)
+			}
+			opts.Since = since
+		}
+		if logGrep != "" {
+			re, err := regexp.Compile(logGrep)
+			if err != nil {
+				return fmt.Errorf("❌ 错误: 无效的 --grep 正则表达式: %w", err)
+			}
+			opts.Grep = re
+		}
+		if logLevel != "" {
+			level, err := process.ParseLevel(logLevel)
+			if err != nil {
+				return fmt.Errorf("❌ 错误: %w", err)
+			}
+			opts.Level = level
 		}
 
 		// === 调用 process 包的 TailLog 逻辑 ===
-		if err := process.TailLog(*found); err != nil {
-			return fmt.Errorf("❌ 追踪进程 %s 的日志失败: %w", found.Name, err)
+		if err := process.TailLogs(found, opts); err != nil {
+			return fmt.Errorf("❌ 追踪进程 %s 的日志失败: %w", strings.Join(args, " "), err)
 		}
 
 		return nil
@@ -44,5 +96,10 @@ var logCmd = &cobra.Command{
 
 // 将 logCmd 注册到 rootCmd
 func init() {
+	logCmd.Flags().IntVarP(&logLines, "lines", "n", 0, "先输出最近 N 行历史")
+	logCmd.Flags().StringVar(&logSince, "since", "", "只输出该时间之后的日志，例如 10m、2h、2026-10-01T10:00")
+	logCmd.Flags().BoolVar(&logNoFollow, "no-follow", false, "输出历史后退出，不继续追踪")
+	logCmd.Flags().StringVar(&logGrep, "grep", "", "只输出匹配该正则表达式的行")
+	logCmd.Flags().StringVar(&logLevel, "level", "", "只输出不低于该级别的行 (debug/info/warn/error/fatal)")
 	rootCmd.AddCommand(logCmd)
 }
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"procmate/pkg/process"
+
+	"github.com/spf13/cobra"
+)
+
+// manageCmd 定义 manage 子命令，恢复 watch 对进程的守护
+var manageCmd = &cobra.Command{
+	Use:   "manage [process-name...|@group|all]",
+	Short: "恢复 watch 对进程的守护 ▶️",
+	Long: `清除进程的 unmanage 或 stop 标记，watch 会在下次检查时拉起离线的进程。
+守护状态保存在 runtime_dir 中，不需要 watch 正在运行。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setSupervision(args, "")
+	},
+}
+
+// unmanageCmd 定义 unmanage 子命令，暂停 watch 对进程的守护
+var unmanageCmd = &cobra.Command{
+	Use:   "unmanage [process-name...|@group|all]",
+	Short: "暂停 watch 对进程的守护，用于维护 ⏸️",
+	Long: `暂停守护后 watch 不会拉起或因就绪超时停止这些进程，也不运行其中的定时任务，
+直到执行 manage。不会启动或停止进程本身，之后的 start / stop 也不会改变暂停状态。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setSupervision(args, process.SupervisionUnmanaged)
+	},
+}
+
+// setSupervision 设置 targets 的守护状态，state 为空时恢复守护。
+func setSupervision(targets []string, state string) error {
+	_, requested, invalidNames := resolveProcesses(targets)
+	if len(invalidNames) > 0 {
+		fmt.Printf("⚠️ 警告：以下服务名称无效或未启用: %s\n", strings.Join(invalidNames, ", "))
+	}
+	if len(requested) == 0 {
+		return fmt.Errorf("❌ 没有找到要设置的进程")
+	}
+	for _, proc := range requested {
+		if err := process.SetSupervision(proc.Name, state); err != nil {
+			return fmt.Errorf("❌ 保存进程 '%s' 的守护状态失败: %w", proc.Name, err)
+		}
+		if state == "" {
+			fmt.Printf("▶️ 进程 '%s' 已恢复守护\n", proc.Name)
+		} else {
+			fmt.Printf("⏸️ 进程 '%s' 已暂停守护\n", proc.Name)
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(manageCmd)
+	rootCmd.AddCommand(unmanageCmd)
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// reloadCmd 定义了 "reload" 子命令
+// 让正在运行的 watch 守护进程重新加载配置文件
+var reloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "让 watch 守护进程重新加载配置文件 🔄",
+	Long: `通知正在运行的 watch 守护进程重新读取配置文件。
+新增的进程会在下一次巡检时被启动；被移除的进程不会被自动停止。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connectDaemon()
+		if err != nil {
+			return fmt.Errorf("❌ %w", err)
+		}
+		if client == nil {
+			fmt.Println("🤔 watch 守护进程未运行，其他命令每次执行时都会读取最新的配置文件。")
+			return nil
+		}
+
+		resp, err := client.Reload()
+		if err != nil {
+			return fmt.Errorf("❌ %w", err)
+		}
+		fmt.Println("✅ 守护进程已重新加载配置文件")
+		if len(resp.Added) > 0 {
+			fmt.Printf("  新增: %s\n", strings.Join(resp.Added, ", "))
+		}
+		if len(resp.Removed) > 0 {
+			fmt.Printf("  移除: %s (不会被自动停止)\n", strings.Join(resp.Removed, ", "))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reloadCmd)
+}
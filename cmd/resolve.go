@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"strings"
+
+	"procmate/pkg/config"
+)
+
+// resolveProcesses 将命令行参数解析为进程列表。
+// 支持以下写法：
+//   - all: 所有已启用的进程
+//   - <name>: 指定名称的进程；对于多实例进程，原始名称代表其所有实例
+//   - @<group>: 'group' 配置为该值的所有进程
+//
+// 返回所有已启用的进程、请求的进程，以及无法识别的名称。
+func resolveProcesses(args []string) (allEnabled []config.Process, requested []config.Process, invalid []string) {
+	allEnabledMap := make(map[string]config.Process) // 用于快速查找和验证
+	instancesOf := make(map[string][]config.Process) // K: 原始进程名, V: 所有实例
+	for _, p := range config.Current().Processes {
+		if p.Enabled {
+			allEnabled = append(allEnabled, p)
+			allEnabledMap[p.Name] = p
+			if p.InstanceOf != "" {
+				instancesOf[p.InstanceOf] = append(instancesOf[p.InstanceOf], p)
+			}
+		}
+	}
+
+	if len(args) > 0 && args[0] == "all" {
+		return allEnabled, allEnabled, nil
+	}
+
+	seen := make(map[string]bool)
+	add := func(p config.Process) {
+		if !seen[p.Name] {
+			seen[p.Name] = true
+			requested = append(requested, p)
+		}
+	}
+
+	for _, name := range args {
+		if group, ok := strings.CutPrefix(name, "@"); ok {
+			matched := false
+			for _, p := range allEnabled {
+				if p.Group == group {
+					add(p)
+					matched = true
+				}
+			}
+			if !matched {
+				invalid = append(invalid, name)
+			}
+			continue
+		}
+
+		// 使用 "comma-ok" 语法进行存在性检查
+		if p, ok := allEnabledMap[name]; ok {
+			add(p)
+		} else if instances, ok := instancesOf[name]; ok {
+			for _, p := range instances {
+				add(p)
+			}
+		} else {
+			invalid = append(invalid, name)
+		}
+	}
+	return allEnabled, requested, invalid
+}
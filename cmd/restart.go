@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// restartForceFreePort 为 true 时，端口被之前由 procmate 启动的残留进程占用，会先停止该进程
+var restartForceFreePort bool
+
+// restartCmd 定义了 "restart" 子命令
+// 先按依赖关系停止进程，再按依赖关系启动
+var restartCmd = &cobra.Command{
+	Use:   "restart [service1 service2...|@group|all]",
+	Short: "重启一个或多个进程 🔁",
+	Long: `先按依赖关系分层并行停止进程，再按依赖关系分层并行启动。
+watch 守护进程运行时，重启操作由守护进程执行。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 守护进程运行时，由守护进程统一执行重启
+		client, err := connectDaemon()
+		if err != nil {
+			return fmt.Errorf("❌ %w", err)
+		}
+		if client != nil {
+			fmt.Println("📡 检测到 watch 守护进程，通过其控制接口重启...")
+			resp, err := client.Restart(args, restartForceFreePort)
+			if err != nil {
+				return fmt.Errorf("❌ %w", err)
+			}
+			printActionResponse(resp, "重启")
+			return nil
+		}
+
+		stopResults, invalidNames, err := stopTargets(args, false)
+		if len(invalidNames) > 0 {
+			fmt.Printf("⚠️ 警告：以下服务名称无效或未启用: %s\n", strings.Join(invalidNames, ", "))
+		}
+		if err != nil {
+			return fmt.Errorf("❌ %w", err)
+		}
+		if len(stopResults) == 0 {
+			fmt.Println("🤔 没有指定要重启的进程，或者没有已启用的进程。")
+			return nil
+		}
+		for _, r := range stopResults {
+			if !r.Success && r.WasRunning {
+				return fmt.Errorf("❌ 进程 %s 未能停止，取消重启", r.Process.Name)
+			}
+		}
+
+		startResults, _, err := startTargets(args, restartForceFreePort)
+		if err != nil {
+			return fmt.Errorf("❌ %w", err)
+		}
+		clearStopped(startResults)
+		return nil
+	},
+}
+
+func init() {
+	restartCmd.Flags().BoolVar(&restartForceFreePort, "force-free-port", false, "端口被之前由 procmate 启动的残留进程占用时，先停止该进程")
+	rootCmd.AddCommand(restartCmd)
+}
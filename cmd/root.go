@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 
 	"procmate/pkg/config" // 引入我们自己写的 config 包
+	"procmate/pkg/process"
 
 	"github.com/spf13/cobra" // 引入 cobra
 )
@@ -13,6 +14,12 @@ import (
 // cfgFile 是一个包级私有变量，用于存储 --config 标志传入的配置文件路径。
 var cfgFile string
 
+// configPath 是实际加载的配置文件路径，守护进程重新加载配置时使用。
+var configPath string
+
+// lockWait 对应 --wait 标志：锁被其他 procmate 占用时等待，而不是立即失败。
+var lockWait bool
+
 // rootCmd 代表了我们应用的根命令。
 // 当不带任何子命令直接调用应用时，执行的就是它。
 var rootCmd = &cobra.Command{
@@ -57,11 +64,14 @@ func init() {
 	// "": 默认值。
 	// "配置文件路径 (默认为 ./config.yaml)": 帮助信息。
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "f", "", "./config.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&lockWait, "wait", false, "进程正被其他 procmate 操作时等待其完成，而不是立即失败")
 }
 
 // initConfig 函数是我们注册给 cobra.OnInitialize 的函数。
 // 它负责调用我们之前写好的 config.LoadConfig 来加载配置。
 func initConfig() {
+	process.LockWait = lockWait
+
 	// 1. 如果用户通过 --config 标志提供了路径，则优先使用它。
 	if cfgFile != "" {
 		if err := config.LoadConfig(cfgFile); err != nil {
@@ -69,6 +79,7 @@ func initConfig() {
 			os.Exit(1)
 		}
 		// fmt.Printf("成功加载指定的配置文件: %s\n", cfgFile)
+		configPath = cfgFile
 		return
 	}
 
@@ -95,6 +106,7 @@ func initConfig() {
 			}
 			// (可选) 打印成功加载信息
 			// fmt.Printf("成功加载配置文件: %s\n", path)
+			configPath = path
 			return // 找到并成功加载后，立即返回
 		}
 	}
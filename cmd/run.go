@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"procmate/pkg/config"
+	"procmate/pkg/process"
+
+	"github.com/spf13/cobra"
+)
+
+// runCmd 定义了 "run" 子命令
+// 在前台运行进程 (类似 foreman / overmind)，适合本地开发时直接使用同一份配置文件
+var runCmd = &cobra.Command{
+	Use:   "run [service1 service2...|@group|all]",
+	Short: "在前台运行进程，日志直接输出到终端 🖥️",
+	Long: `按依赖关系启动指定的进程 (包括其依赖)，并在前台运行。
+
+- 所有进程的日志带有着色的前缀，直接输出到终端。
+- 按 Ctrl+C 或任意一个进程退出时，按依赖关系逆序停止所有进程。
+- PID 文件和托管日志写入一个临时目录，退出后自动清理，不影响 runtime_dir 中由 start/watch 管理的进程。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 1. 解析并确定请求运行的服务列表
+		allEnabledProcesses, requestedProcesses, invalidNames := resolveProcesses(args)
+		if len(invalidNames) > 0 {
+			fmt.Printf("⚠️ 警告：以下服务名称无效或未启用: %s\n", strings.Join(invalidNames, ", "))
+		}
+		// 定时任务由 watch 按计划运行，不适合前台运行
+		var services []config.Process
+		for _, proc := range requestedProcesses {
+			if proc.Schedule != "" {
+				fmt.Printf("⏭️ 跳过定时任务 '%s'，定时任务只由 watch 按计划运行。\n", proc.Name)
+				continue
+			}
+			services = append(services, proc)
+		}
+		requestedProcesses = services
+		if len(requestedProcesses) == 0 {
+			fmt.Println("🤔 没有指定要运行的进程，或者没有已启用的进程。")
+			return nil
+		}
+
+		// 2. 获取分层执行计划 (包括依赖)
+		executionLayers, err := process.GetExecutionLayers(allEnabledProcesses, requestedProcesses)
+		if err != nil {
+			return fmt.Errorf("❌ 无法确定启动计划: %w", err)
+		}
+		var processes []config.Process
+		for _, layer := range executionLayers {
+			processes = append(processes, layer...)
+		}
+
+		// 3. 使用临时的运行时目录，运行结束后清理
+		runtimeDir, err := os.MkdirTemp("", "procmate-run-")
+		if err != nil {
+			return fmt.Errorf("❌ 创建临时运行时目录失败: %w", err)
+		}
+		defer os.RemoveAll(runtimeDir)
+		config.UseRuntimeDir(runtimeDir)
+
+		// 4. 在启动之前开始输出日志，启动过程中的输出也能看到
+		stopLogs, err := process.StreamLogs(processes)
+		if err != nil {
+			return fmt.Errorf("❌ 无法输出日志: %w", err)
+		}
+
+		// 收到 Ctrl+C 时取消启动，或结束运行
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(sigs)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-sigs
+			fmt.Println("\n🛑 收到退出信号，正在停止所有进程...")
+			cancel()
+		}()
+
+		// 5. 启动进程，任意进程启动失败时停止运行
+		manager := process.NewParallelStartManager(process.GetDefaultParallelStartOptions())
+		if _, err := manager.StartProcessesInLayers(executionLayers, ctx); err != nil {
+			fmt.Printf("❌ 启动失败: %v\n", err)
+			cancel()
+		}
+
+		// 6. 等待 Ctrl+C 或任意进程退出
+		ticker := time.NewTicker(time.Second)
+	wait:
+		for ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+				break wait
+			case <-ticker.C:
+				for _, proc := range processes {
+					if running, _ := process.IsRunning(proc); !running {
+						fmt.Printf("\033[33m⚠️ 进程 '%s' 已退出，正在停止所有进程...\033[0m\n", proc.Name)
+						cancel()
+						break
+					}
+				}
+			}
+		}
+		ticker.Stop()
+
+		// 7. 按依赖关系逆序停止所有进程
+		stopManager := process.NewParallelStopManager(process.GetDefaultParallelStopOptions())
+		if _, err := stopManager.StopProcessesInLayers(executionLayers, context.Background()); err != nil {
+			fmt.Printf("❌ 停止进程失败: %v\n", err)
+		}
+
+		// 等待进程最后的输出写入日志后再停止输出
+		time.Sleep(500 * time.Millisecond)
+		stopLogs()
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"procmate/pkg/config"
+	"procmate/pkg/control"
+	"procmate/pkg/process"
+
+	"github.com/spf13/cobra"
+)
+
+// scaleCmd 定义了 "scale" 子命令
+// 调整多实例进程的实例数量，新增的实例会被启动，多余的实例会被停止
+var scaleCmd = &cobra.Command{
+	Use:   "scale name=N [name=N...]",
+	Short: "调整多实例进程的实例数量 📈",
+	Long: `调整配置了 instances 的进程的实例数量。
+
+调整结果会持久化到 runtime_dir 中，之后的 status、watch 等命令都会使用新的实例数量。
+新增的实例会立即启动，多余的实例（序号较大的）会被停止。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var toStart []config.Process
+		var toStop []config.Process
+
+		// 守护进程运行时，由守护进程执行停止和启动；否则在调整期间独占全局锁
+		client, err := connectDaemon()
+		if err != nil {
+			return fmt.Errorf("❌ %w", err)
+		}
+		if client == nil {
+			lock, err := process.LockGlobal(true)
+			if err != nil {
+				return fmt.Errorf("❌ %w", err)
+			}
+			defer lock.Unlock()
+		}
+
+		for _, arg := range args {
+			// 1. 解析 name=N
+			name, countStr, ok := strings.Cut(arg, "=")
+			if !ok {
+				return fmt.Errorf("❌ 参数格式错误 '%s'，应为 name=N", arg)
+			}
+			count, err := strconv.Atoi(countStr)
+			if err != nil {
+				return fmt.Errorf("❌ 实例数量无效 '%s': %w", countStr, err)
+			}
+
+			// 2. 调整并持久化实例数量
+			before, after, err := config.Scale(name, count)
+			if err != nil {
+				return fmt.Errorf("❌ 调整进程 '%s' 失败: %w", name, err)
+			}
+			fmt.Printf("📈 进程 '%s' 实例数量: %d -> %d\n", name, len(before), len(after))
+
+			// 3. 计算需要启动和停止的实例
+			afterNames := make(map[string]bool, len(after))
+			for _, p := range after {
+				afterNames[p.Name] = true
+			}
+			for _, p := range before {
+				if !afterNames[p.Name] {
+					toStop = append(toStop, p)
+				}
+			}
+			for _, p := range after {
+				if p.Enabled {
+					toStart = append(toStart, p)
+				}
+			}
+		}
+
+		if client != nil {
+			return scaleViaDaemon(client, toStop, toStart)
+		}
+
+		ctx := context.Background()
+
+		// 4. 停止多余的实例
+		if len(toStop) > 0 {
+			manager := process.NewParallelStopManager(process.GetDefaultParallelStopOptions())
+			if _, err := manager.StopProcessesInLayers([][]config.Process{toStop}, ctx); err != nil {
+				return fmt.Errorf("❌ 停止多余实例失败: %w", err)
+			}
+		}
+
+		// 5. 启动新增的实例（已运行的实例会被自动跳过）
+		if len(toStart) > 0 {
+			allEnabledProcesses, _, _ := resolveProcesses(nil)
+			executionLayers, err := process.GetExecutionLayers(allEnabledProcesses, toStart)
+			if err != nil {
+				return fmt.Errorf("❌ 无法确定启动计划: %w", err)
+			}
+			manager := process.NewParallelStartManager(process.GetSmartParallelStartOptions())
+			if _, err := manager.StartProcessesInLayers(executionLayers, ctx); err != nil {
+				return fmt.Errorf("❌ 启动新增实例失败: %w", err)
+			}
+		}
+
+		return nil
+	},
+}
+
+// scaleViaDaemon 通过守护进程的控制接口停止多余的实例、重新加载配置并启动新增的实例。
+// 多余的实例必须在重新加载之前停止，否则守护进程已不再认识它们。
+func scaleViaDaemon(client *control.Client, toStop, toStart []config.Process) error {
+	fmt.Println("📡 检测到 watch 守护进程，通过其控制接口调整实例...")
+	names := func(procs []config.Process) []string {
+		var result []string
+		for _, p := range procs {
+			result = append(result, p.Name)
+		}
+		return result
+	}
+
+	if len(toStop) > 0 {
+		resp, err := client.StopOnly(names(toStop))
+		if err != nil {
+			return fmt.Errorf("❌ 停止多余实例失败: %w", err)
+		}
+		printActionResponse(resp, "停止")
+	}
+
+	if _, err := client.Reload(); err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+
+	if len(toStart) > 0 {
+		resp, err := client.Start(names(toStart), false)
+		if err != nil {
+			return fmt.Errorf("❌ 启动新增实例失败: %w", err)
+		}
+		printActionResponse(resp, "启动")
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(scaleCmd)
+}
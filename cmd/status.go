@@ -6,40 +6,85 @@ import (
 	"procmate/pkg/config"
 	"procmate/pkg/process"
 	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/olekukonko/tablewriter/renderer"
 	"github.com/spf13/cobra"
 )
 
+var (
+	statusDetail bool
+	statusExits  int
+)
+
 // statusCmd 代表 'procmate status' 命令
 var statusCmd = &cobra.Command{
-	Use:   "status",
+	Use:   "status [process-name...|@group]",
 	Short: "检查并显示所有已定义进程的状态 🔛",
 	Long: `遍历配置文件中定义的所有进程，通过检查其PID文件和系统信息
-来确定它们的详细运行时状态，并以表格形式显示结果。`,
+来确定它们的详细运行时状态，并以表格形式显示结果。
+
+指定进程名时只显示这些进程。加上 --detail 时逐个显示详细信息：
+子进程树、线程数、打开的文件描述符、读写字节数、命令行、工作目录、环境变量，
+以及重启次数和最近的退出记录 (仅由 watch / run 启动的进程会被记录)。`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// 步骤 1: 遍历进程，将所有行数据收集到一个切片中
-		var tableData [][]string
-		for _, proc := range config.Cfg.Processes {
-			if !proc.Enabled {
-				continue
+		if statusExits < 0 {
+			return fmt.Errorf("❌ --exits 不能为负数: %d", statusExits)
+		}
+
+		// 指定了进程名时只显示这些进程
+		var selected map[string]bool
+		if len(args) > 0 {
+			_, requested, invalidNames := resolveProcesses(args)
+			if len(invalidNames) > 0 {
+				fmt.Printf("⚠️ 警告：以下服务名称无效或未启用: %s\n", strings.Join(invalidNames, ", "))
+			}
+			if len(requested) == 0 {
+				return fmt.Errorf("❌ 没有找到要查看的进程")
+			}
+			selected = make(map[string]bool)
+			for _, p := range requested {
+				selected[p.Name] = true
 			}
+		}
+
+		if statusDetail {
+			return printProcessDetails(selected)
+		}
 
-			info, err := process.GetProcessInfo(proc)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "获取进程 '%s' 信息时发生意外错误: %v\n", proc.Name, err)
-				continue
+		// 步骤 1: 获取所有进程的信息，守护进程运行时由守护进程提供
+		all, err := collectProcessInfos()
+		if err != nil {
+			return fmt.Errorf("❌ %w", err)
+		}
+		var infos []process.ProcessInfo
+		for _, info := range all {
+			if selected == nil || selected[info.Name] {
+				infos = append(infos, info)
 			}
+		}
+
+		// 步骤 2: 遍历进程，将所有行数据收集到一个切片中
+		var tableData [][]string
+		for _, info := range infos {
 
 			var row []string
-			if info.IsRunning {
+			if info.TimedOut {
+				row = []string{info.Name, "-", "❔ UNKNOWN (timeout)", "-", "-", "-", "-", "-"}
+			} else if info.IsRunning {
 				var status = "♻️ RUNNING"
 
-				if info.IsReady {
+				if info.Schedule != "" {
+					status = "⏰ JOB RUNNING"
+				} else if info.IsReady {
 					status = "✅ READY"
 				}
 
+				if info.Supervision != "" {
+					status += " (" + info.Supervision + ")"
+				}
+
 				portsStr := strings.Join(info.ListeningPorts, ",")
 				if portsStr == "" {
 					portsStr = "-"
@@ -49,12 +94,21 @@ var statusCmd = &cobra.Command{
 					fmt.Sprintf("%d", info.PID),
 					status,
 					info.Uptime.String(),
-					fmt.Sprintf("%.1f%%", info.CPUPercent),
+					formatCPU(info),
 					fmt.Sprintf("%.1fMB", info.MemoryRSS),
 					portsStr,
+					formatCgroupUsage(info.Cgroup),
 				}
 			} else {
 				status := "❌ OFFLINE"
+				switch {
+				case info.Supervision == process.SupervisionStopped:
+					status = "⏹️ STOPPED"
+				case info.Supervision == process.SupervisionUnmanaged:
+					status = "⏸️ UNMANAGED"
+				case info.Schedule != "":
+					status = fmt.Sprintf("⏰ SCHEDULED (上次: %s，下次: %s)", formatLastRun(info.LastRun), formatNextRun(info.NextRun))
+				}
 				row = []string{
 					info.Name,
 					"-",
@@ -63,16 +117,21 @@ var statusCmd = &cobra.Command{
 					"-",
 					"-",
 					"-",
+					"-",
 				}
 			}
 			tableData = append(tableData, row)
 		}
 
-		// 步骤 2: 完全按照示例的简洁风格进行渲染
+		if paused, since := process.GetWatchPaused(); paused {
+			fmt.Printf("⏸️ watch 守护已暂停 (自 %s)，使用 'procmate watch --resume' 恢复。\n", since.Format("2006-01-02 15:04:05"))
+		}
+
+		// 步骤 3: 完全按照示例的简洁风格进行渲染
 		table := tablewriter.NewTable(os.Stdout,
 			tablewriter.WithRenderer(renderer.NewMarkdown()),
 		)
-		table.Header("NAME", "PID", "STATUS", "UPTIME", "CPU%", "MEM(RSS)", "LISTENING")
+		table.Header("NAME", "PID", "STATUS", "UPTIME", "CPU%", "MEM(RSS)", "LISTENING", "CGROUP")
 
 		table.Bulk(tableData)
 
@@ -82,6 +141,236 @@ var statusCmd = &cobra.Command{
 	},
 }
 
+// collectProcessInfos 获取所有已启用进程的运行时信息。
+// watch 守护进程运行时通过其控制接口获取，否则直接读取 PID 文件和系统信息。
+func collectProcessInfos() ([]process.ProcessInfo, error) {
+	client, err := connectDaemon()
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client.Processes()
+	}
+
+	var procs []config.Process
+	for _, proc := range config.Current().Processes {
+		if proc.Enabled {
+			procs = append(procs, proc)
+		}
+	}
+	return process.GetProcessInfos(procs)
+}
+
+// formatCPU 格式化 CPU 使用率，守护进程提供了滚动平均值时一并显示。
+func formatCPU(info process.ProcessInfo) string {
+	cpu := fmt.Sprintf("%.1f%%", info.CPUPercent)
+	if info.CPUAvg1m != nil && info.CPUAvg5m != nil {
+		cpu += fmt.Sprintf(" (1m %.1f%% / 5m %.1f%%)", *info.CPUAvg1m, *info.CPUAvg5m)
+	}
+	return cpu
+}
+
+// printProcessDetails 逐个显示进程的详细信息，selected 为 nil 时显示所有已启用的进程。
+func printProcessDetails(selected map[string]bool) error {
+	first := true
+	for _, proc := range config.Current().Processes {
+		if !proc.Enabled || (selected != nil && !selected[proc.Name]) {
+			continue
+		}
+		detail, err := process.GetProcessDetail(proc)
+		if err != nil {
+			return fmt.Errorf("❌ 获取进程 '%s' 的详细信息失败: %w", proc.Name, err)
+		}
+		if !first {
+			fmt.Println()
+		}
+		first = false
+		printProcessDetail(detail)
+	}
+	return nil
+}
+
+// printProcessDetail 显示单个进程的详细信息。
+func printProcessDetail(d *process.ProcessDetail) {
+	field := func(label, value string) {
+		if value == "" {
+			value = "-"
+		}
+		fmt.Printf("  %s %s\n", padRight(label+":", 10), value)
+	}
+
+	fmt.Printf("📋 %s\n", d.Name)
+	switch {
+	case d.TimedOut:
+		field("状态", "❔ UNKNOWN (timeout)")
+	case !d.IsRunning && d.Schedule != "":
+		field("状态", "⏰ SCHEDULED")
+	case !d.IsRunning:
+		field("状态", "❌ OFFLINE")
+	case d.Schedule != "":
+		field("状态", fmt.Sprintf("⏰ JOB RUNNING (PID %d，已运行 %s)", d.PID, d.Uptime))
+	case d.IsReady:
+		field("状态", fmt.Sprintf("✅ READY (PID %d，已运行 %s)", d.PID, d.Uptime))
+	default:
+		field("状态", fmt.Sprintf("♻️ RUNNING (PID %d，已运行 %s)", d.PID, d.Uptime))
+	}
+
+	if d.IsRunning {
+		field("命令行", d.Cmdline)
+		field("工作目录", d.Cwd)
+		field("CPU", formatCPU(d.ProcessInfo))
+		field("内存", fmt.Sprintf("%.1fMB", d.MemoryRSS))
+		field("线程数", countOrDash(int64(d.Threads)))
+		field("打开文件", countOrDash(int64(d.OpenFDs)))
+		field("IO", fmt.Sprintf("读 %s / 写 %s", formatBytes(d.ReadBytes), formatBytes(d.WriteBytes)))
+		field("监听端口", strings.Join(d.ListeningPorts, ","))
+		if d.Cgroup != nil {
+			field("cgroup", formatCgroupUsage(d.Cgroup))
+		}
+	}
+	field("守护", formatSupervision(d.Supervision))
+	if d.Schedule != "" {
+		field("计划", d.Schedule)
+		field("上次运行", formatLastRun(d.LastRun))
+		field("下次运行", formatNextRun(d.NextRun))
+		field("跳过次数", fmt.Sprintf("%d", d.SkippedRuns))
+	} else {
+		field("重启次数", fmt.Sprintf("%d", d.History.Restarts))
+	}
+
+	if d.IsRunning {
+		if len(d.Children) == 0 {
+			field("子进程", "")
+		} else {
+			fmt.Println("  子进程:")
+			printChildTree(d.Children, "    ")
+		}
+	}
+
+	exits := d.History.Exits
+	if len(exits) > statusExits {
+		exits = exits[:statusExits]
+	}
+	if len(exits) == 0 {
+		field("最近退出", "")
+	} else {
+		fmt.Println("  最近退出:")
+		for _, e := range exits {
+			result := fmt.Sprintf("退出码 %d", e.Code)
+			if e.Signal != "" {
+				result = "信号 " + e.Signal
+			}
+			fmt.Printf("    %s  %s\n", e.Time.Format("2006-01-02 15:04:05"), result)
+		}
+	}
+
+	if d.IsRunning {
+		if len(d.Env) == 0 {
+			field("环境变量", "")
+		} else {
+			fmt.Println("  环境变量:")
+			for _, kv := range d.Env {
+				fmt.Printf("    %s\n", kv)
+			}
+		}
+	}
+}
+
+// formatSupervision 显示进程的守护状态。
+func formatSupervision(state string) string {
+	switch state {
+	case process.SupervisionStopped:
+		return "⏹️ 已被 stop 停止，不会自动重启，直到再次 start"
+	case process.SupervisionUnmanaged:
+		return "⏸️ 已暂停守护，直到 manage"
+	default:
+		return "正常"
+	}
+}
+
+// formatLastRun 显示定时任务的上次运行时间和结果。
+func formatLastRun(run *process.JobRun) string {
+	if run == nil {
+		return "尚未运行"
+	}
+	started := run.Started.Local().Format("01-02 15:04:05")
+	switch {
+	case run.Exit == nil:
+		// 由已退出的 procmate 启动时无法观察到退出状态
+		return started + " 未记录结果"
+	case run.Exit.Signal != "":
+		return fmt.Sprintf("%s 信号 %s", started, run.Exit.Signal)
+	default:
+		return fmt.Sprintf("%s 退出码 %d，耗时 %s", started, run.Exit.Code, run.Exit.Time.Sub(run.Started).Round(time.Second))
+	}
+}
+
+// formatNextRun 显示定时任务的下次计划运行时间。
+func formatNextRun(next *time.Time) string {
+	if next == nil {
+		return "无效的 schedule"
+	}
+	return next.Local().Format("01-02 15:04:05")
+}
+
+// printChildTree 以树形显示子进程。
+func printChildTree(children []process.ChildProcess, indent string) {
+	for i, child := range children {
+		branch, next := "├─ ", "│  "
+		if i == len(children)-1 {
+			branch, next = "└─ ", "   "
+		}
+		fmt.Printf("%s%s%d %s\n", indent, branch, child.PID, child.Cmdline)
+		printChildTree(child.Children, indent+next)
+	}
+}
+
+// countOrDash 格式化数量，0 (通常表示无权读取) 显示为 "-"。
+func countOrDash(n int64) string {
+	if n <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// formatBytes 将字节数格式化为易读的形式。
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value := float64(n) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f%s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1fTB", value)
+}
+
+// formatCgroupUsage 将 cgroup 资源使用情况格式化为 "mem 已用/上限 pids 已用/上限"。
+func formatCgroupUsage(usage *process.CgroupUsage) string {
+	if usage == nil {
+		return "-"
+	}
+	limit := func(v int64, format func(int64) string) string {
+		if v < 0 {
+			return "max"
+		}
+		return format(v)
+	}
+	mb := func(v int64) string { return fmt.Sprintf("%.1fMB", float64(v)/1024/1024) }
+	num := func(v int64) string { return fmt.Sprintf("%d", v) }
+
+	return fmt.Sprintf("mem %s/%s pids %d/%s",
+		mb(usage.MemoryCurrent), limit(usage.MemoryMax, mb),
+		usage.PidsCurrent, limit(usage.PidsMax, num))
+}
+
 func init() {
+	statusCmd.Flags().BoolVar(&statusDetail, "detail", false, "显示进程的详细信息")
+	statusCmd.Flags().IntVar(&statusExits, "exits", 5, "--detail 时显示最近多少次退出记录")
+	statusCmd.Flags().DurationVar(&process.CPUSampleInterval, "cpu-interval", 0, "计算 CPU 使用率时两次采样的间隔 (默认使用配置中的 cpu_sample_ms，未配置时为 250ms)")
 	rootCmd.AddCommand(statusCmd)
 }
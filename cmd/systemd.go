@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"procmate/pkg/config"
+	"procmate/pkg/process"
+
+	"github.com/spf13/cobra"
+)
+
+// systemd generate 命令的参数
+var (
+	systemdPerProcess bool
+	systemdOutput     string
+	systemdBinary     string
+)
+
+// systemdCmd 是 systemd 相关子命令的父命令
+var systemdCmd = &cobra.Command{
+	Use:   "systemd",
+	Short: "systemd 集成 🧩",
+}
+
+// systemdGenerateCmd 定义 systemd generate 子命令，生成 systemd 单元文件
+var systemdGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "生成 systemd 单元文件",
+	Long: `默认生成以 watch 守护所有进程的 procmate.service (Type=notify，带看门狗)。
+加上 --per-process 时为每个已启用的进程生成一个 procmate-<name>.service，
+由 systemd 直接管理，依赖关系 (depends_on) 转换为 After= / Requires=，定时任务会被跳过。
+
+未指定 --output 时输出到标准输出，指定后写入该目录：
+
+  procmate systemd generate > /etc/systemd/system/procmate.service
+  procmate systemd generate --per-process --output /etc/systemd/system`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		units := make(map[string]string)
+		var names []string
+		if systemdPerProcess {
+			enabled := make(map[string]bool)
+			for _, p := range config.Current().Processes {
+				if p.Enabled {
+					enabled[p.Name] = true
+				}
+			}
+			for _, p := range config.Current().Processes {
+				if !p.Enabled {
+					continue
+				}
+				unit, err := process.GenerateProcessUnit(p)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "⚠️ 无法生成单元: %v\n", err)
+					continue
+				}
+				for _, dep := range p.DependsOn {
+					if !enabled[dep] {
+						fmt.Fprintf(os.Stderr, "⚠️ 进程 '%s' 依赖的 '%s' 未启用，已从 Requires= 中忽略\n", p.Name, dep)
+					}
+				}
+				name := process.SystemdUnitName(p.Name)
+				units[name] = unit
+				names = append(names, name)
+			}
+		} else {
+			bin, err := resolveBinary()
+			if err != nil {
+				return fmt.Errorf("❌ %w", err)
+			}
+			cfg, err := filepath.Abs(configPath)
+			if err != nil {
+				return fmt.Errorf("❌ 获取配置文件路径失败: %w", err)
+			}
+			units["procmate.service"] = process.GenerateMainUnit(bin, cfg)
+			names = append(names, "procmate.service")
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("❌ 没有可生成单元的进程")
+		}
+
+		if systemdOutput == "" {
+			for i, name := range names {
+				if i > 0 {
+					fmt.Println()
+				}
+				if len(names) > 1 {
+					fmt.Printf("# ==> %s <==\n", name)
+				}
+				fmt.Print(units[name])
+			}
+			return nil
+		}
+
+		if err := os.MkdirAll(systemdOutput, 0755); err != nil {
+			return fmt.Errorf("❌ 创建目录 %s 失败: %w", systemdOutput, err)
+		}
+		for _, name := range names {
+			path := filepath.Join(systemdOutput, name)
+			if err := os.WriteFile(path, []byte(units[name]), 0644); err != nil {
+				return fmt.Errorf("❌ 写入 %s 失败: %w", path, err)
+			}
+			fmt.Printf("✅ 已写入 %s\n", path)
+		}
+		fmt.Println("💡 执行 'systemctl daemon-reload' 后使用 'systemctl enable --now <单元>' 启用。")
+		return nil
+	},
+}
+
+// resolveBinary 返回写入 ExecStart= 的 procmate 可执行文件的绝对路径。
+func resolveBinary() (string, error) {
+	if systemdBinary != "" {
+		return filepath.Abs(systemdBinary)
+	}
+	bin, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("获取 procmate 可执行文件路径失败: %w", err)
+	}
+	return bin, nil
+}
+
+func init() {
+	systemdGenerateCmd.Flags().BoolVar(&systemdPerProcess, "per-process", false, "为每个进程生成一个单元，由 systemd 直接管理")
+	systemdGenerateCmd.Flags().StringVar(&systemdOutput, "output", "", "将单元文件写入该目录，而不是输出到标准输出")
+	systemdGenerateCmd.Flags().StringVar(&systemdBinary, "bin", "", "ExecStart= 中使用的 procmate 路径 (默认为当前可执行文件)")
+	systemdCmd.AddCommand(systemdGenerateCmd)
+	rootCmd.AddCommand(systemdCmd)
+}
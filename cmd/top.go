@@ -0,0 +1,765 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"procmate/pkg/config"
+	"procmate/pkg/control"
+	"procmate/pkg/process"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+	"github.com/spf13/cobra"
+)
+
+// topLogLines 是日志窗格保留的最大行数
+const topLogLines = 500
+
+// topCmd 定义了 "top" 子命令
+// 交互式的终端界面，实时刷新所有进程的状态，并可直接启动、停止、重启进程
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "交互式查看和操作进程 📊",
+	Long: `以交互式终端界面实时显示所有已启用进程的状态，每秒刷新一次。
+
+按键：
+  ↑/↓ 或 k/j   选择进程
+  Enter/空格    折叠或展开分组
+  s / x / r     启动 / 停止 / 重启选中的进程 (选中分组时作用于整个分组)
+  c / m / u / n 按 CPU / 内存 / 运行时间 / 名称排序，再按一次切换升降序
+  l             显示或隐藏日志窗格
+  q / Esc       退出`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		screen, err := tcell.NewScreen()
+		if err != nil {
+			return fmt.Errorf("❌ 初始化终端失败: %w", err)
+		}
+		if err := screen.Init(); err != nil {
+			return fmt.Errorf("❌ 初始化终端失败: %w", err)
+		}
+
+		// 界面运行期间，进程管理相关的输出改为显示在底部状态栏
+		restoreOutput, err := captureOutput(func(line string) {
+			screen.PostEvent(tcell.NewEventInterrupt(line))
+		})
+		if err != nil {
+			screen.Fini()
+			return fmt.Errorf("❌ %w", err)
+		}
+
+		v := newTopView(screen)
+		v.run()
+
+		screen.Fini()
+		restoreOutput()
+		return nil
+	},
+}
+
+// topSortKey 是 top 界面的排序字段，空字符串表示按配置顺序
+type topSortKey string
+
+const (
+	topSortNone   topSortKey = ""
+	topSortCPU    topSortKey = "CPU"
+	topSortMem    topSortKey = "内存"
+	topSortUptime topSortKey = "运行时间"
+	topSortName   topSortKey = "名称"
+)
+
+// topRow 是表格中的一行：分组标题或进程
+type topRow struct {
+	group   string
+	header  bool
+	members []process.ProcessInfo // 分组标题行对应的进程
+	info    process.ProcessInfo
+}
+
+// key 唯一标识一行，刷新和排序后用于保持选中状态
+func (r topRow) key() string {
+	if r.header {
+		return "@" + r.group
+	}
+	return r.info.Name
+}
+
+// target 返回对该行执行操作时使用的名称
+func (r topRow) target() string {
+	return r.key()
+}
+
+// topView 保存 top 界面的状态。界面在主循环中绘制，其它协程修改状态后通过事件通知刷新。
+type topView struct {
+	screen tcell.Screen
+
+	mu        sync.Mutex
+	infos     []process.ProcessInfo
+	groups    map[string]string // 进程名 -> 分组
+	sortKey   topSortKey
+	sortDesc  bool
+	folded    map[string]bool
+	selected  string // 选中行的 key
+	offset    int    // 表格滚动位置
+	showLog   bool
+	logTarget string
+	logLines  []string
+	stopLog   context.CancelFunc
+	busy      bool
+	actions   sync.WaitGroup // 正在后台执行的操作
+	message   string
+}
+
+func newTopView(screen tcell.Screen) *topView {
+	groups := make(map[string]string)
+	for _, p := range config.Current().Processes {
+		groups[p.Name] = p.Group
+	}
+	return &topView{
+		screen:  screen,
+		groups:  groups,
+		folded:  make(map[string]bool),
+		showLog: true,
+		message: "按 q 退出",
+	}
+}
+
+// run 运行界面主循环，直到用户退出。
+func (v *topView) run() {
+	// 退出前等待刷新协程结束，之后才能恢复标准输出
+	ctx, cancel := context.WithCancel(context.Background())
+	var refreshing sync.WaitGroup
+	refreshing.Add(1)
+	defer refreshing.Wait()
+	defer cancel()
+	go func() {
+		defer refreshing.Done()
+		v.refreshLoop(ctx)
+	}()
+
+	for {
+		v.draw()
+		switch ev := v.screen.PollEvent().(type) {
+		case *tcell.EventResize:
+			v.screen.Sync()
+		case *tcell.EventInterrupt:
+			if line, ok := ev.Data().(string); ok {
+				v.mu.Lock()
+				if strings.TrimSpace(line) != "" {
+					v.message = line
+				}
+				v.mu.Unlock()
+			}
+		case *tcell.EventKey:
+			if !v.handleKey(ev) {
+				v.mu.Lock()
+				if v.stopLog != nil {
+					v.stopLog()
+				}
+				busy := v.busy
+				if busy {
+					v.message = "⏳ 等待当前操作完成后退出..."
+				}
+				v.mu.Unlock()
+				// 中途退出会留下启动了一半的进程，等待正在执行的操作完成
+				if busy {
+					v.draw()
+				}
+				v.actions.Wait()
+				return
+			}
+		}
+	}
+}
+
+// refreshLoop 每秒刷新一次进程信息。
+func (v *topView) refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		infos, err := collectProcessInfos()
+		v.mu.Lock()
+		if err != nil {
+			v.message = fmt.Sprintf("❌ 获取进程信息失败: %v", err)
+		} else {
+			v.infos = infos
+		}
+		v.mu.Unlock()
+		v.screen.PostEvent(tcell.NewEventInterrupt(nil))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// handleKey 处理按键，返回 false 表示退出。
+func (v *topView) handleKey(ev *tcell.EventKey) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	rows := v.rows()
+	index := v.selectedIndex(rows)
+	move := func(delta int) {
+		if len(rows) == 0 {
+			return
+		}
+		index = max(0, min(len(rows)-1, index+delta))
+		v.selected = rows[index].key()
+	}
+
+	switch ev.Key() {
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		return false
+	case tcell.KeyUp:
+		move(-1)
+	case tcell.KeyDown:
+		move(1)
+	case tcell.KeyPgUp:
+		move(-10)
+	case tcell.KeyPgDn:
+		move(10)
+	case tcell.KeyHome:
+		move(-len(rows))
+	case tcell.KeyEnd:
+		move(len(rows))
+	case tcell.KeyEnter:
+		v.toggleFold(rows, index)
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'q':
+			return false
+		case 'k':
+			move(-1)
+		case 'j':
+			move(1)
+		case ' ':
+			v.toggleFold(rows, index)
+		case 'c':
+			v.setSort(topSortCPU)
+		case 'm':
+			v.setSort(topSortMem)
+		case 'u':
+			v.setSort(topSortUptime)
+		case 'n':
+			v.setSort(topSortName)
+		case 'l':
+			v.showLog = !v.showLog
+			if !v.showLog && v.stopLog != nil {
+				v.stopLog()
+				v.stopLog = nil
+				v.logTarget = ""
+			}
+		case 's':
+			v.runAction(rows, index, topStart, "启动")
+		case 'x':
+			v.runAction(rows, index, topStop, "停止")
+		case 'r':
+			v.runAction(rows, index, topRestart, "重启")
+		}
+	}
+	return true
+}
+
+// setSort 切换排序字段；再次选择同一字段时切换升降序。
+func (v *topView) setSort(key topSortKey) {
+	if v.sortKey == key {
+		v.sortDesc = !v.sortDesc
+		return
+	}
+	v.sortKey = key
+	// 资源占用默认从高到低，名称默认从 A 到 Z
+	v.sortDesc = key != topSortName
+}
+
+// toggleFold 折叠或展开选中行所在的分组。
+func (v *topView) toggleFold(rows []topRow, index int) {
+	if index < 0 || index >= len(rows) || rows[index].group == "" {
+		return
+	}
+	group := rows[index].group
+	v.folded[group] = !v.folded[group]
+	v.selected = "@" + group
+}
+
+// runAction 在后台对选中的行执行启动、停止或重启。
+func (v *topView) runAction(rows []topRow, index int, action func(target string) error, verb string) {
+	if index < 0 || index >= len(rows) {
+		return
+	}
+	if v.busy {
+		v.message = "⏳ 上一个操作尚未完成"
+		return
+	}
+	target := rows[index].target()
+	v.busy = true
+	v.message = fmt.Sprintf("⏳ 正在%s %s...", verb, target)
+
+	v.actions.Add(1)
+	go func() {
+		defer v.actions.Done()
+		err := action(target)
+		v.mu.Lock()
+		v.busy = false
+		if err != nil {
+			v.message = fmt.Sprintf("❌ %s %s 失败: %v", verb, target, err)
+		} else {
+			v.message = fmt.Sprintf("✅ 已%s %s", verb, target)
+		}
+		v.mu.Unlock()
+		v.screen.PostEvent(tcell.NewEventInterrupt(nil))
+	}()
+}
+
+// topStart 启动 target (包括其依赖)，watch 守护进程运行时由守护进程执行。
+func topStart(target string) error {
+	client, err := connectDaemon()
+	if err != nil {
+		return err
+	}
+	if client != nil {
+		resp, err := client.Start([]string{target}, false)
+		if err != nil {
+			return err
+		}
+		return actionResponseError(resp)
+	}
+	results, _, err := startTargets([]string{target}, false)
+	if err != nil {
+		return err
+	}
+	clearStopped(results)
+	var failed []string
+	for _, r := range results {
+		if !r.Success {
+			failed = append(failed, r.Process.Name)
+		}
+	}
+	return failedProcessesError(failed)
+}
+
+// topStop 停止 target (包括依赖它的进程)，watch 守护进程运行时由守护进程执行。
+func topStop(target string) error {
+	client, err := connectDaemon()
+	if err != nil {
+		return err
+	}
+	if client != nil {
+		resp, err := client.Stop([]string{target})
+		if err != nil {
+			return err
+		}
+		return actionResponseError(resp)
+	}
+	results, _, err := stopTargets([]string{target}, false)
+	if err != nil {
+		return err
+	}
+	markStopped(results)
+	var failed []string
+	for _, r := range results {
+		if !r.Success && r.WasRunning {
+			failed = append(failed, r.Process.Name)
+		}
+	}
+	return failedProcessesError(failed)
+}
+
+// topRestart 重启 target，有进程未能停止时不再启动。
+func topRestart(target string) error {
+	client, err := connectDaemon()
+	if err != nil {
+		return err
+	}
+	if client != nil {
+		resp, err := client.Restart([]string{target}, false)
+		if err != nil {
+			return err
+		}
+		return actionResponseError(resp)
+	}
+	results, _, err := stopTargets([]string{target}, false)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, r := range results {
+		if !r.Success && r.WasRunning {
+			failed = append(failed, r.Process.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("进程 %s 未能停止，取消重启", strings.Join(failed, ", "))
+	}
+	return topStart(target)
+}
+
+// actionResponseError 将守护进程返回的操作结果中失败的进程转换为错误。
+func actionResponseError(resp *control.ActionResponse) error {
+	var failed []string
+	for _, r := range resp.Results {
+		if !r.Success {
+			failed = append(failed, r.Process)
+		}
+	}
+	return failedProcessesError(failed)
+}
+
+// failedProcessesError 在有进程操作失败时返回列出这些进程的错误。
+func failedProcessesError(failed []string) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("进程 %s 操作失败", strings.Join(failed, ", "))
+}
+
+// rows 按当前的排序和折叠状态生成表格的所有行。调用方需持有 v.mu。
+func (v *topView) rows() []topRow {
+	infos := make([]process.ProcessInfo, len(v.infos))
+	copy(infos, v.infos)
+
+	less := func(a, b process.ProcessInfo) bool {
+		switch v.sortKey {
+		case topSortCPU:
+			return a.CPUPercent < b.CPUPercent
+		case topSortMem:
+			return a.MemoryRSS < b.MemoryRSS
+		case topSortUptime:
+			return a.Uptime < b.Uptime
+		default:
+			return a.Name < b.Name
+		}
+	}
+	if v.sortKey != topSortNone {
+		sort.SliceStable(infos, func(i, j int) bool {
+			if v.sortDesc {
+				return less(infos[j], infos[i])
+			}
+			return less(infos[i], infos[j])
+		})
+	}
+
+	// 分组按其排在最前的成员的位置显示，成员紧随分组标题
+	members := make(map[string][]process.ProcessInfo)
+	for _, info := range infos {
+		if group := v.groups[info.Name]; group != "" {
+			members[group] = append(members[group], info)
+		}
+	}
+	var rows []topRow
+	emitted := make(map[string]bool)
+	for _, info := range infos {
+		group := v.groups[info.Name]
+		if group == "" {
+			rows = append(rows, topRow{info: info})
+			continue
+		}
+		if emitted[group] {
+			continue
+		}
+		emitted[group] = true
+		rows = append(rows, topRow{group: group, header: true, members: members[group]})
+		if !v.folded[group] {
+			for _, m := range members[group] {
+				rows = append(rows, topRow{group: group, info: m})
+			}
+		}
+	}
+	return rows
+}
+
+// selectedIndex 返回选中行的位置，选中的行不存在时 (如所在分组被折叠) 选中其分组或第一行。
+func (v *topView) selectedIndex(rows []topRow) int {
+	for i, r := range rows {
+		if r.key() == v.selected {
+			return i
+		}
+	}
+	group := v.groups[v.selected]
+	for i, r := range rows {
+		if r.header && r.group == group {
+			v.selected = r.key()
+			return i
+		}
+	}
+	if len(rows) > 0 {
+		v.selected = rows[0].key()
+	}
+	return 0
+}
+
+// followLog 让日志窗格跟随选中的行。调用方需持有 v.mu。
+func (v *topView) followLog(target string) {
+	if target == v.logTarget {
+		return
+	}
+	if v.stopLog != nil {
+		v.stopLog()
+		v.stopLog = nil
+	}
+	v.logTarget = target
+	v.logLines = nil
+	if target == "" {
+		return
+	}
+
+	_, procs, _ := resolveProcesses([]string{target})
+	if len(procs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	v.stopLog = cancel
+	multiple := len(procs) > 1
+	go func() {
+		opts := process.TailOptions{Lines: 200, Follow: true}
+		err := process.StreamLogLines(ctx, procs, opts, func(line process.LogLine) {
+			text := line.Line
+			if multiple || line.Source != line.Process {
+				text = fmt.Sprintf("[%s] %s", line.Source, text)
+			}
+			v.mu.Lock()
+			if v.logTarget == target && ctx.Err() == nil {
+				v.logLines = append(v.logLines, text)
+				if len(v.logLines) > topLogLines {
+					v.logLines = v.logLines[len(v.logLines)-topLogLines:]
+				}
+			}
+			v.mu.Unlock()
+			v.screen.PostEvent(tcell.NewEventInterrupt(nil))
+		})
+		if err != nil {
+			v.mu.Lock()
+			if v.logTarget == target {
+				v.logLines = append(v.logLines, fmt.Sprintf("❌ 读取日志失败: %v", err))
+			}
+			v.mu.Unlock()
+		}
+	}()
+}
+
+// draw 绘制整个界面。
+func (v *topView) draw() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s := v.screen
+	s.Clear()
+	width, height := s.Size()
+
+	rows := v.rows()
+	index := v.selectedIndex(rows)
+	if v.showLog && len(rows) > 0 {
+		v.followLog(rows[index].target())
+	}
+
+	// 标题栏
+	title := " procmate top"
+	sortDesc := "配置顺序"
+	if v.sortKey != topSortNone {
+		arrow := "↑"
+		if v.sortDesc {
+			arrow = "↓"
+		}
+		sortDesc = string(v.sortKey) + arrow
+	}
+	title += fmt.Sprintf("   %d 个进程   排序: %s   %s", len(v.infos), sortDesc, time.Now().Format("15:04:05"))
+	drawText(s, 0, 0, width, tcell.StyleDefault.Reverse(true), padRight(title, width))
+
+	// 表格区域：开启日志窗格时占上半部分
+	tableTop := 1
+	tableHeight := height - 2
+	if v.showLog {
+		tableHeight = (height - 2) / 2
+	}
+
+	nameWidth := 12
+	for _, r := range rows {
+		nameWidth = max(nameWidth, runewidth.StringWidth(r.key())+4)
+	}
+	nameWidth = min(nameWidth, 32)
+	header := fmt.Sprintf(" %s %8s  %-10s %10s %7s %10s  %s",
+		padRight("NAME", nameWidth), "PID", "STATUS", "UPTIME", "CPU%", "MEM(RSS)", "LISTENING")
+	drawText(s, 0, tableTop, width, tcell.StyleDefault.Bold(true), header)
+
+	visible := tableHeight - 1
+	if index < v.offset {
+		v.offset = index
+	}
+	if visible > 0 && index >= v.offset+visible {
+		v.offset = index - visible + 1
+	}
+	for i := v.offset; i < len(rows) && i-v.offset < visible; i++ {
+		y := tableTop + 1 + i - v.offset
+		style := tcell.StyleDefault
+		if i == index {
+			style = style.Reverse(true)
+		}
+		v.drawRow(s, y, width, nameWidth, rows[i], style)
+	}
+
+	// 日志窗格
+	if v.showLog {
+		logTop := tableTop + tableHeight
+		label := " 日志 "
+		if v.logTarget != "" {
+			label = fmt.Sprintf(" 日志: %s ", v.logTarget)
+		}
+		drawText(s, 0, logTop, width, tcell.StyleDefault.Dim(true), "──"+label+strings.Repeat("─", width))
+		logHeight := height - 2 - logTop
+		lines := v.logLines
+		if len(lines) > logHeight {
+			lines = lines[len(lines)-logHeight:]
+		}
+		for i, line := range lines {
+			drawText(s, 0, logTop+1+i, width, tcell.StyleDefault, line)
+		}
+	}
+
+	// 状态栏
+	help := " ↑↓ 选择  ⏎ 折叠  s 启动  x 停止  r 重启  c/m/u/n 排序  l 日志  q 退出 "
+	drawText(s, 0, height-1, width, tcell.StyleDefault.Reverse(true), padRight(help+" "+v.message, width))
+
+	s.Show()
+}
+
+// drawRow 绘制表格中的一行。
+func (v *topView) drawRow(s tcell.Screen, y, width, nameWidth int, r topRow, style tcell.Style) {
+	if r.header {
+		arrow := "▾"
+		if v.folded[r.group] {
+			arrow = "▸"
+		}
+		running := 0
+		var cpu, mem float64
+		for _, m := range r.members {
+			if m.IsRunning {
+				running++
+			}
+			cpu += m.CPUPercent
+			mem += m.MemoryRSS
+		}
+		name := fmt.Sprintf("%s @%s", arrow, r.group)
+		text := fmt.Sprintf(" %s %8s  %-10s %10s %6.1f%% %8.1fMB",
+			padRight(name, nameWidth), "", fmt.Sprintf("%d/%d", running, len(r.members)), "", cpu, mem)
+		drawText(s, 0, y, width, style.Bold(true), padRight(text, width))
+		return
+	}
+
+	info := r.info
+	name := info.Name
+	if r.group != "" {
+		name = "  " + name
+	}
+	text := " " + padRight(name, nameWidth)
+	statusStyle := style
+	if info.TimedOut {
+		text += fmt.Sprintf(" %8s  ", "-")
+		drawText(s, 0, y, width, style, padRight(text, width))
+		drawText(s, runewidth.StringWidth(text), y, width, statusStyle.Foreground(tcell.ColorDarkGray), "UNKNOWN (timeout)")
+		return
+	}
+	if !info.IsRunning && info.Schedule != "" && info.Supervision == "" {
+		text += fmt.Sprintf(" %8s  ", "-")
+		drawText(s, 0, y, width, style, padRight(text, width))
+		drawText(s, runewidth.StringWidth(text), y, width, statusStyle.Foreground(tcell.ColorBlue),
+			"SCHEDULED (next "+formatNextRun(info.NextRun)+")")
+		return
+	}
+	if !info.IsRunning && info.Supervision != "" {
+		text += fmt.Sprintf(" %8s  ", "-")
+		drawText(s, 0, y, width, style, padRight(text, width))
+		drawText(s, runewidth.StringWidth(text), y, width, statusStyle.Foreground(tcell.ColorDarkGray), strings.ToUpper(info.Supervision))
+		return
+	}
+	if !info.IsRunning {
+		text += fmt.Sprintf(" %8s  ", "-")
+		drawText(s, 0, y, width, style, padRight(text, width))
+		drawText(s, runewidth.StringWidth(text), y, width, statusStyle.Foreground(tcell.ColorRed), "OFFLINE")
+		return
+	}
+
+	status := "RUNNING"
+	statusStyle = statusStyle.Foreground(tcell.ColorYellow)
+	if info.Schedule != "" {
+		status = "JOB"
+		statusStyle = style.Foreground(tcell.ColorBlue)
+	} else if info.IsReady {
+		status = "READY"
+		statusStyle = style.Foreground(tcell.ColorGreen)
+	}
+	ports := strings.Join(info.ListeningPorts, ",")
+	if ports == "" {
+		ports = "-"
+	}
+	text += fmt.Sprintf(" %8d  ", info.PID)
+	statusX := runewidth.StringWidth(text)
+	text += fmt.Sprintf("%-10s %10s %6.1f%% %8.1fMB  %s",
+		status, info.Uptime.Truncate(time.Second), info.CPUPercent, info.MemoryRSS, ports)
+	drawText(s, 0, y, width, style, padRight(text, width))
+	drawText(s, statusX, y, width, statusStyle, status)
+}
+
+// drawText 从 (x, y) 开始绘制文本，超出 width 的部分被截断。
+func drawText(s tcell.Screen, x, y, width int, style tcell.Style, text string) {
+	for _, r := range text {
+		w := runewidth.RuneWidth(r)
+		if w == 0 {
+			continue
+		}
+		if x+w > width {
+			return
+		}
+		s.SetContent(x, y, r, nil, style)
+		x += w
+	}
+}
+
+// padRight 用空格将文本补齐到 width 列。
+func padRight(text string, width int) string {
+	if n := runewidth.StringWidth(text); n < width {
+		return text + strings.Repeat(" ", width-n)
+	}
+	return runewidth.Truncate(text, width, "…")
+}
+
+// captureOutput 将标准输出和标准错误重定向到管道，逐行交给 fn，返回恢复原输出的函数。
+// 终端界面直接操作终端设备，不受影响。替换和恢复时不能有其他协程在运行，
+// 界面中会产生输出的后台协程 (刷新、操作) 都在两者之间启动并结束。
+func captureOutput(fn func(string)) (func(), error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("创建管道失败: %w", err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, w
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			fn(scanner.Text())
+		}
+	}()
+
+	return func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		w.Close()
+		<-done
+		r.Close()
+	}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(topCmd)
+}
@@ -5,27 +5,89 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"procmate/pkg/config"
+	"procmate/pkg/control"
+	"procmate/pkg/notify"
 	"procmate/pkg/process"
 
 	"github.com/spf13/cobra"
 )
 
+// watch 命令的参数
+var (
+	watchPause  bool
+	watchResume bool
+)
+
 var watchCmd = &cobra.Command{
 	Use:   "watch",
 	Short: "启动守护模式，持续监控并自动重启已关闭的进程 🛡️",
 	Long: `这是一个长期运行的命令。它会周期性地检查所有已启用进程的状态，
-如果发现某个进程离线，则会自动尝试重新启动它。`,
+如果发现某个进程离线，则会自动尝试重新启动它。
+
+守护进程运行期间会在 runtime_dir 中提供控制接口 (procmate.sock)，
+start / stop / restart / status / reload 等命令会通过该接口交由守护进程执行。
+通过 stop 停止的进程不会被自动重启，直到再次 start；通过 unmanage 暂停守护的进程
+直到 manage 之前都不会被拉起。守护状态保存在 runtime_dir 中，守护进程重启后依然有效。
+
+  procmate watch --pause    # 暂停守护 (不拉起任何进程，也不运行定时任务)，正在运行的守护进程立即生效
+  procmate watch --resume   # 恢复守护`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if watchPause || watchResume {
+			return setWatchPaused(watchPause)
+		}
+
+		if _, err := parseWatchExitPolicy(config.Current().Settings.OnWatchExit); err != nil {
+			return fmt.Errorf("❌ %w", err)
+		}
+
 		fmt.Println("✅ procmate 守护模式已启动... (sh下按 Ctrl+C 退出)")
 
-		watchInterval := config.Cfg.Settings.WatchIntervalSec
+		// 守护进程总是等待其他 procmate 释放锁，而不是跳过本次操作
+		process.LockWait = true
+
+		watchInterval := config.Current().Settings.WatchIntervalSec
 
 		fmt.Printf("每 %d 秒检查一次所有已启用进程的状态。\n", watchInterval)
 
+		// 按配置开启 Prometheus 指标接口
+		if addr := config.Current().Settings.MetricsListen; addr != "" {
+			server, err := process.ServeMetrics(addr)
+			if err != nil {
+				return fmt.Errorf("❌ 启动指标接口失败: %w", err)
+			}
+			defer server.Close()
+			fmt.Printf("📈 指标接口已开启: http://%s/metrics\n", addr)
+		}
+
+		// 开启控制接口
+		if err := os.MkdirAll(config.RuntimeDir(), 0755); err != nil {
+			return fmt.Errorf("❌ 创建运行时目录失败: %w", err)
+		}
+		settings := config.Current().Settings
+		socketPath := control.SocketPath(config.RuntimeDir())
+		controlServer, err := control.Serve(daemonBackend{}, socketPath, settings.ControlListen, settings.ControlToken)
+		if err != nil {
+			return fmt.Errorf("❌ 启动控制接口失败: %w", err)
+		}
+		defer controlServer.Close()
+		fmt.Printf("🔌 控制接口已开启: %s\n", socketPath)
+		if settings.ControlListen != "" {
+			fmt.Printf("🔌 控制接口已开启: http://%s\n", settings.ControlListen)
+		}
+
+		// 跟踪 CPU 使用率，计算 1 分钟 / 5 分钟平均值
+		trackerCtx, stopTracker := context.WithCancel(context.Background())
+		defer stopTracker()
+		process.StartCPUTracker(trackerCtx, cpuTrackInterval, enabledProcesses)
+
+		// 按 schedule 定时运行定时任务
+		process.StartScheduler(trackerCtx, supervisedProcesses)
+
 		// 创建定时器，每 watchInterval 秒触发一次
 		ticker := time.NewTicker(time.Duration(watchInterval) * time.Second)
 
@@ -35,8 +97,9 @@ var watchCmd = &cobra.Command{
 		quitChannel := make(chan os.Signal, 1)
 		signal.Notify(quitChannel, syscall.SIGINT, syscall.SIGTERM)
 
-		// 立即执行一次检查
+		// 立即执行一次检查，完成首次启动后通知 systemd 服务已就绪
 		checkAndRestartProcesses()
+		notifySystemd("READY=1\nSTATUS=" + watchStatusSummary())
 
 		// 主循环
 		for {
@@ -44,36 +107,86 @@ var watchCmd = &cobra.Command{
 			case <-ticker.C:
 				fmt.Println("\n⏰ [TICK] 周期性检查开始...")
 				checkAndRestartProcesses()
+				notifySystemd("WATCHDOG=1\nSTATUS=" + watchStatusSummary())
 			case <-quitChannel:
 				fmt.Println("\n🛑 收到退出信号，正在关闭守护进程...")
+				notifySystemd("STOPPING=1\nSTATUS=正在退出")
+				// 先停止调度器，避免停止过程中又运行新的定时任务
+				stopTracker()
+				stopOnWatchExit()
 				return nil
 			}
 		}
 	},
 }
 
+// cpuTrackInterval 是守护进程计算 CPU 滚动平均值的采样周期
+const cpuTrackInterval = 5 * time.Second
+
+// watchedProcesses 记录已经被守护进程检查过的进程，用于区分首次启动和重启
+var watchedProcesses = make(map[string]bool)
+
+// lastReady 记录上次检查时运行中的进程是否就绪，用于记录就绪状态的变化
+var lastReady = make(map[string]bool)
+
 // checkAndRestartProcesses 封装单次检查和重启逻辑
 func checkAndRestartProcesses() {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	lock, err := process.LockGlobal(false)
+	if err != nil {
+		fmt.Printf("\033[31m❌ %v\033[0m\n", err)
+		return
+	}
+	defer lock.Unlock()
+
+	if paused, since := process.GetWatchPaused(); paused {
+		fmt.Printf("⏸️ 守护已暂停 (自 %s)，跳过本次检查。使用 'procmate watch --resume' 恢复。\n", since.Format("2006-01-02 15:04:05"))
+		return
+	}
+
 	var needRestartProcesses []config.Process
 	var timeoutProcesses []config.Process
+	defer func() {
+		for _, proc := range config.Current().Processes {
+			watchedProcesses[proc.Name] = true
+		}
+	}()
 
 	// 第一轮：检查所有进程状态，收集需要处理的进程
-	for _, proc := range config.Cfg.Processes {
+	for _, proc := range config.Current().Processes {
 		if !proc.Enabled {
 			continue
 		}
+		if !process.IsSupervised(proc.Name) {
+			// 被运维人员停止或暂停守护的进程，不自动重启
+			continue
+		}
+		if proc.Schedule != "" {
+			// 定时任务由调度器按计划运行，运行结束后不需要重启
+			continue
+		}
 
 		// 检查是否运行正常
 		isRunning, _ := process.IsRunning(proc)
-		isReady, _ := process.IsReady(proc)
+		isReady, _ := process.CheckReady(proc)
 
 		if isRunning {
+			wasReady, known := lastReady[proc.Name]
+			lastReady[proc.Name] = isReady
 			if isReady {
 				// 绿色表示运行且就绪
 				fmt.Printf("\033[32m✔️ 进程 '%s' 运行中且就绪\033[0m\n", proc.Name)
+				if known && !wasReady {
+					process.RecordEvent(process.Event{Process: proc.Name, Type: process.EventReady, Message: "恢复就绪"})
+				}
 			} else {
 				// 黄色表示运行但未就绪
 				fmt.Printf("\033[33m♻️ 进程 '%s' 运行中，但未就绪\033[0m\n", proc.Name)
+				if known && wasReady {
+					process.RecordEvent(process.Event{Process: proc.Name, Type: process.EventUnready})
+				}
 
 				// 检查是否超时，如果超时收集起来统一处理
 				if isProcessTimeoutNoReady(proc) {
@@ -83,6 +196,16 @@ func checkAndRestartProcesses() {
 		} else {
 			// 红色 🚨 表示离线警告
 			fmt.Printf("\033[31m🚨 警告: 进程 '%s' 离线！\033[0m\n", proc.Name)
+			delete(lastReady, proc.Name)
+			// 重启时会一并启动其依赖，依赖被停止或暂停守护时不能替运维人员拉起
+			if dep := unsupervisedDependency(proc); dep != "" {
+				fmt.Printf("⏸️ 进程 '%s' 依赖的 '%s' 已被停止或暂停守护，暂不重启。\n", proc.Name, dep)
+				continue
+			}
+			if watchedProcesses[proc.Name] {
+				process.RecordEvent(process.Event{Process: proc.Name, Type: process.EventOffline})
+				notify.Send(config.Current().Settings.Notifications, notify.EventOffline, proc.Name, offlineMessage(proc))
+			}
 			needRestartProcesses = append(needRestartProcesses, proc)
 		}
 	}
@@ -91,6 +214,7 @@ func checkAndRestartProcesses() {
 	if len(timeoutProcesses) > 0 {
 		fmt.Printf("\n🚨 发现 %d 个超时进程，正在终止...\n", len(timeoutProcesses))
 		for _, proc := range timeoutProcesses {
+			notify.Send(config.Current().Settings.Notifications, notify.EventProbeFailed, proc.Name, "运行超过启动超时时间仍未就绪，将被终止并重启")
 			if err := process.Stop(proc); err != nil {
 				fmt.Printf("\033[31m❌ 终止超时进程 '%s' 失败: %v\033[0m\n", proc.Name, err)
 			} else {
@@ -104,10 +228,16 @@ func checkAndRestartProcesses() {
 	// 第三轮：并行重启需要重启的进程
 	if len(needRestartProcesses) > 0 {
 		fmt.Printf("\n⚡ 发现 %d 个离线进程，正在并行重启...\n", len(needRestartProcesses))
+		for _, proc := range needRestartProcesses {
+			// 守护进程启动时拉起离线进程不算重启
+			if watchedProcesses[proc.Name] {
+				process.RecordRestart(proc.Name)
+			}
+		}
 
 		var allEnabledProcesses []config.Process                  // 用于传递给函数
 		allEnabledProcessesMap := make(map[string]config.Process) // 用于快速查找和验证
-		for _, p := range config.Cfg.Processes {
+		for _, p := range config.Current().Processes {
 			if p.Enabled {
 				allEnabledProcesses = append(allEnabledProcesses, p)
 				allEnabledProcessesMap[p.Name] = p
@@ -119,12 +249,264 @@ func checkAndRestartProcesses() {
 
 		executionLayers, err := process.GetExecutionLayers(allEnabledProcesses, needRestartProcesses)
 		if err != nil {
-			fmt.Errorf("❌ 无法确定启动计划: %w")
+			fmt.Printf("\033[31m❌ 无法确定启动计划: %v\033[0m\n", err)
+			return
 		}
 
-		_, err = manager.StartProcessesInLayers(executionLayers, ctx)
+		layerResults, err := manager.StartProcessesInLayers(executionLayers, ctx)
 		if err != nil {
-			fmt.Errorf("❌ 并行启动失败: %w")
+			fmt.Printf("\033[31m❌ 并行启动失败: %v\033[0m\n", err)
+		}
+		notifyRestartResults(needRestartProcesses, layerResults)
+	}
+}
+
+// watchExitPolicy 是解析后的 settings.on_watch_exit。
+type watchExitPolicy struct {
+	stop  bool   // 是否停止进程
+	group string // 只停止该分组中的进程，为空表示所有进程
+}
+
+// parseWatchExitPolicy 解析 on_watch_exit：leave (默认) / stop_all / stop_group:<x>。
+func parseWatchExitPolicy(s string) (watchExitPolicy, error) {
+	switch {
+	case s == "" || s == "leave":
+		return watchExitPolicy{}, nil
+	case s == "stop_all":
+		return watchExitPolicy{stop: true}, nil
+	case strings.HasPrefix(s, "stop_group:") && len(s) > len("stop_group:"):
+		return watchExitPolicy{stop: true, group: strings.TrimPrefix(s, "stop_group:")}, nil
+	default:
+		return watchExitPolicy{}, fmt.Errorf("无效的 on_watch_exit '%s'，可选 leave / stop_all / stop_group:<分组>", s)
+	}
+}
+
+// stopOnWatchExit 按 on_watch_exit 在守护进程退出前停止受管进程：按依赖关系逆序分层并行停止，
+// 总耗时不超过 watch_exit_timeout_sec，时限已到仍在运行的进程会被强制终止。
+// unmanage 暂停守护的进程不由 watch 启停，这里同样不停止。
+func stopOnWatchExit() {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	settings := config.Current().Settings
+	policy, err := parseWatchExitPolicy(settings.OnWatchExit)
+	if err != nil {
+		fmt.Printf("⚠️ %v，保留所有进程。\n", err)
+		return
+	}
+	if !policy.stop {
+		fmt.Println("👋 保留所有受管进程继续运行 (on_watch_exit: leave)。")
+		return
+	}
+
+	var allEnabledProcesses, targets []config.Process
+	for _, p := range config.Current().Processes {
+		if !p.Enabled {
+			continue
+		}
+		allEnabledProcesses = append(allEnabledProcesses, p)
+		if policy.group != "" && p.Group != policy.group {
+			continue
+		}
+		if !process.IsSupervised(p.Name) {
+			fmt.Printf("⏭️ '%s' 已暂停守护，保留运行。\n", p.Name)
+			continue
+		}
+		targets = append(targets, p)
+	}
+	if len(targets) == 0 {
+		if policy.group != "" {
+			fmt.Printf("🤔 分组 '%s' 中没有需要停止的进程。\n", policy.group)
+		} else {
+			fmt.Println("🤔 没有需要停止的进程。")
+		}
+		return
+	}
+
+	// 执行计划会包含分组外的依赖，只停止目标进程本身
+	executionLayers, err := process.GetExecutionLayers(allEnabledProcesses, targets)
+	if err != nil {
+		fmt.Printf("⚠️ 无法确定停止计划: %v，将同时停止所有目标进程。\n", err)
+		executionLayers = [][]config.Process{targets}
+	}
+	isTarget := make(map[string]bool)
+	for _, p := range targets {
+		isTarget[p.Name] = true
+	}
+	var layers [][]config.Process
+	for _, layer := range executionLayers {
+		var filtered []config.Process
+		for _, p := range layer {
+			if isTarget[p.Name] {
+				filtered = append(filtered, p)
+			}
+		}
+		if len(filtered) > 0 {
+			layers = append(layers, filtered)
+		}
+	}
+
+	timeout := process.DefaultWatchExitTimeout
+	if settings.WatchExitTimeoutSec > 0 {
+		timeout = time.Duration(settings.WatchExitTimeoutSec) * time.Second
+	}
+	fmt.Printf("🛑 正在停止受管进程 (on_watch_exit: %s，时限 %v)...\n", settings.OnWatchExit, timeout)
+
+	// 不获取全局锁，避免等待其他 procmate 超出时限；每个进程在停止时仍会持有自己的进程锁
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	options := process.GetDefaultParallelStopOptions()
+	options.LayerTimeout = timeout
+	options.ProcessTimeout = timeout
+	manager := process.NewParallelStopManager(options)
+	if _, err := manager.StopProcessesInLayers(layers, ctx); err != nil {
+		fmt.Printf("⚠️ %v\n", err)
+	}
+
+	// 时限已到仍在运行的进程直接强制终止
+	for _, p := range targets {
+		if running, _ := process.IsRunning(p); !running {
+			continue
+		}
+		if err := process.Kill(p, fmt.Sprintf("watch 退出时未能在 %v 内停止", timeout)); err != nil {
+			fmt.Printf("❌ 强制终止进程 '%s' 失败: %v\n", p.Name, err)
+		}
+	}
+}
+
+// notifySystemd 通过 sd_notify 向 systemd 报告守护进程的状态，未在 systemd 下运行时什么都不做。
+func notifySystemd(state string) {
+	if err := process.SdNotify(state); err != nil {
+		fmt.Printf("⚠️ %v\n", err)
+	}
+}
+
+// watchStatusSummary 生成一行进程状态摘要，用于 sd_notify 的 STATUS=，显示在 systemctl status 中。
+func watchStatusSummary() string {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	if paused, _ := process.GetWatchPaused(); paused {
+		return "守护已暂停"
+	}
+	var ready, notReady, offline, held, jobs int
+	for _, proc := range config.Current().Processes {
+		if !proc.Enabled {
+			continue
+		}
+		switch {
+		case !process.IsSupervised(proc.Name):
+			held++
+		case proc.Schedule != "":
+			jobs++
+		default:
+			if running, _ := process.IsRunning(proc); !running {
+				offline++
+			} else if isReady, _ := process.IsReady(proc); isReady {
+				ready++
+			} else {
+				notReady++
+			}
+		}
+	}
+	summary := fmt.Sprintf("%d 个就绪，%d 个未就绪，%d 个离线", ready, notReady, offline)
+	if held > 0 {
+		summary += fmt.Sprintf("，%d 个已停止或暂停守护", held)
+	}
+	if jobs > 0 {
+		summary += fmt.Sprintf("，%d 个定时任务", jobs)
+	}
+	return summary
+}
+
+// supervisedProcesses 返回已启用且未被停止或暂停守护的进程，watch 暂停时返回空。
+func supervisedProcesses() []config.Process {
+	if paused, _ := process.GetWatchPaused(); paused {
+		return nil
+	}
+	var procs []config.Process
+	for _, p := range enabledProcesses() {
+		if process.IsSupervised(p.Name) {
+			procs = append(procs, p)
+		}
+	}
+	return procs
+}
+
+// unsupervisedDependency 返回 proc 的 (间接) 依赖中未在运行且不受守护的进程名，没有时返回空。
+// 调用方需持有 stateMu。
+func unsupervisedDependency(proc config.Process) string {
+	var all []config.Process
+	for _, p := range config.Current().Processes {
+		if p.Enabled {
+			all = append(all, p)
+		}
+	}
+	layers, err := process.GetExecutionLayers(all, []config.Process{proc})
+	if err != nil {
+		return ""
+	}
+	for _, layer := range layers {
+		for _, dep := range layer {
+			if dep.Name == proc.Name || process.IsSupervised(dep.Name) {
+				continue
+			}
+			if running, _ := process.IsRunning(dep); !running {
+				return dep.Name
+			}
+		}
+	}
+	return ""
+}
+
+// setWatchPaused 暂停或恢复 watch 的守护。
+func setWatchPaused(paused bool) error {
+	if err := process.SetWatchPaused(paused); err != nil {
+		return fmt.Errorf("❌ 保存守护状态失败: %w", err)
+	}
+	if paused {
+		fmt.Println("⏸️ 守护已暂停：watch 不会拉起任何进程，也不会运行定时任务。使用 'procmate watch --resume' 恢复。")
+	} else {
+		fmt.Println("▶️ 守护已恢复。")
+	}
+	return nil
+}
+
+// offlineMessage 生成进程离线通知的内容，附带最近一次观察到的退出状态。
+func offlineMessage(proc config.Process) string {
+	message := "进程离线，正在自动重启"
+	history, err := process.GetProcessHistory(proc.Name)
+	if err != nil || len(history.Exits) == 0 {
+		return message
+	}
+	// 只使用最近两个巡检周期内的退出记录，更早的记录与本次离线无关
+	last := history.Exits[0]
+	if time.Since(last.Time) > 2*time.Duration(config.Current().Settings.WatchIntervalSec)*time.Second {
+		return message
+	}
+	if last.Signal != "" {
+		return fmt.Sprintf("%s (被信号 %s 终止)", message, last.Signal)
+	}
+	return fmt.Sprintf("%s (退出码 %d)", message, last.Code)
+}
+
+// notifyRestartResults 为离线进程的自动重启结果发送通知。
+func notifyRestartResults(restarted []config.Process, layerResults []process.LayerResult) {
+	wanted := make(map[string]bool, len(restarted))
+	for _, p := range restarted {
+		wanted[p.Name] = true
+	}
+	settings := config.Current().Settings
+	for _, layer := range layerResults {
+		for _, r := range layer.Results {
+			name := r.Process.Name
+			switch {
+			case !wanted[name]:
+			case !r.Success:
+				notify.Send(settings.Notifications, notify.EventRestartFailed, name, fmt.Sprintf("自动重启失败: %v", r.Error))
+			case !r.IsSkipped && watchedProcesses[name]:
+				notify.Send(settings.Notifications, notify.EventRestart, name, fmt.Sprintf("已自动重启 (PID %d，耗时 %.1fs)", r.PID, r.Duration.Seconds()))
+			}
 		}
 	}
 }
@@ -134,7 +516,7 @@ func isProcessTimeoutNoReady(proc config.Process) bool {
 	// 计算超时时间
 	timeoutSec := proc.StartTimeoutSec
 	if timeoutSec <= 0 {
-		timeoutSec = config.Cfg.Settings.DefaultStartTimeoutSec
+		timeoutSec = config.Current().Settings.DefaultStartTimeoutSec
 	}
 	if timeoutSec <= 0 {
 		timeoutSec = 60 // 提供一个最终的默认值，防止两者都未配置
@@ -150,6 +532,8 @@ func isProcessTimeoutNoReady(proc config.Process) bool {
 	// 如果运行时间超过了配置的超时阈值，则认为进程卡住
 	if info.Uptime > timeoutDuration {
 		fmt.Printf("\033[31m🚨 进程 '%s' 运行已超过 %d 秒但仍未就绪，标记为超时\033[0m\n", proc.Name, int(timeoutDuration.Seconds()))
+		process.RecordEvent(process.Event{Process: proc.Name, Type: process.EventProbeFailed, PID: info.PID,
+			Message: fmt.Sprintf("运行已超过 %d 秒但仍未就绪", int(timeoutDuration.Seconds()))})
 		return true
 	}
 
@@ -157,5 +541,8 @@ func isProcessTimeoutNoReady(proc config.Process) bool {
 }
 
 func init() {
+	watchCmd.Flags().BoolVar(&watchPause, "pause", false, "暂停守护后退出，不启动守护进程")
+	watchCmd.Flags().BoolVar(&watchResume, "resume", false, "恢复守护后退出，不启动守护进程")
+	watchCmd.MarkFlagsMutuallyExclusive("pause", "resume")
 	rootCmd.AddCommand(watchCmd)
 }
@@ -1,7 +1,9 @@
 #!/bin/bash
 
-# 任何命令失败则立即退出，防止不完整的安装
+# 任何命令失败则立即退出，防止不完整的安装；
+# pipefail 使管道中前面的命令 (如 systemd generate) 失败时同样退出，而不是写入不完整的服务文件
 set -e
+set -o pipefail
 
 # === 步骤 1: 解析参数与定义路径 ===
 
@@ -114,22 +116,8 @@ echo ""
 # === 步骤 5: 安装 systemd 服务 ===
 echo "🛠️  正在创建并启用 systemd 服务..."
 
-# 使用 Heredoc 将 service 文件内容直接写入目标路径
-sudo tee "${PROCMATE_SERVICE_TARGET}" > /dev/null <<EOF
-[Unit]
-Description=Procmate Process Manager
-After=network.target
-
-[Service]
-Type=simple
-ExecStart=${PROCMATE_BIN_LINK} watch
-Restart=on-failure
-RestartSec=5s
-User=root
-
-[Install]
-WantedBy=multi-user.target
-EOF
+# 由 procmate 根据配置生成 service 文件 (Type=notify，带看门狗，KillMode 与 on_watch_exit 对应)
+sudo "${PROCMATE_BIN_LINK}" -f "${TARGET_CONFIG_FILE}" systemd generate --bin "${PROCMATE_BIN_LINK}" | sudo tee "${PROCMATE_SERVICE_TARGET}" > /dev/null
 
 # 重载并启用服务
 sudo systemctl daemon-reload
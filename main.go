@@ -1,11 +1,25 @@
 package main
 
 import (
+	"os"
+
 	"procmate/cmd"
+	"procmate/pkg/process"
 )
 
 // 主程序入口
 func main() {
+	// 内置启动器：设置 umask/rlimit 后 exec 目标命令，无需加载配置
+	if len(os.Args) > 1 && os.Args[1] == process.ExecHelperArg {
+		process.RunExecHelper(os.Args[2:])
+		return
+	}
+	// 日志中转进程：读取子进程输出并写入轮转日志
+	if len(os.Args) > 1 && os.Args[1] == process.LogHelperArg {
+		process.RunLogHelper(os.Args[2:])
+		return
+	}
+
 	// fmt.Println("procmate, build~~~~")
 	cmd.Execute()
 }
@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/spf13/viper" // 引入 viper 库
 )
@@ -20,7 +22,45 @@ type Settings struct {
 	DefaultStartTimeoutSec int        `mapstructure:"default_start_timeout_sec"`
 	DefaultStopTimeoutSec  int        `mapstructure:"default_stop_timeout_sec"`
 	WatchIntervalSec       int        `mapstructure:"watch_interval_sec"`
+	LogDir                 string     `mapstructure:"log_dir"`           // 托管日志的根目录，未配置时使用 <runtime_dir>/logs
+	CPUSampleMs            int        `mapstructure:"cpu_sample_ms"`     // 计算 CPU 使用率时两次采样的间隔 (毫秒)，默认 250
+	StatusTimeoutMs        int        `mapstructure:"status_timeout_ms"` // 获取单个进程状态的超时时间 (毫秒)，默认 3000
+	MetricsListen          string     `mapstructure:"metrics_listen"`    // watch 提供 Prometheus /metrics 的监听地址，为空时不开启
+	ControlListen          string     `mapstructure:"control_listen"`    // watch 控制接口额外监听的 TCP 地址，为空时只监听 Unix socket
+	ControlToken           string     `mapstructure:"control_token"`     // 访问 TCP 控制接口需要的 Bearer token
 	LogOptions             LogOptions `mapstructure:"log_options"`
+
+	// watch 收到 SIGINT / SIGTERM 退出时如何处理受管进程：
+	// - leave (默认): 保留所有进程，适合 systemd 重启 procmate 本身
+	// - stop_all: 按依赖关系逆序停止所有已启用的进程，适合关机
+	// - stop_group:<x>: 只停止分组 x 中的进程
+	// watch_exit_timeout_sec 是停止的总时限 (默认 80 秒)，应小于 systemd 的 TimeoutStopSec
+	OnWatchExit         string `mapstructure:"on_watch_exit"`
+	WatchExitTimeoutSec int    `mapstructure:"watch_exit_timeout_sec"`
+
+	// watch 发现进程离线、就绪超时、自动重启 (或重启失败) 时发送的通知
+	Notifications Notifications `mapstructure:"notifications"`
+}
+
+// Notifications 结构体对应 'settings.notifications' 部分。
+type Notifications struct {
+	// 同一个通知渠道对同一进程的同一类事件，在该时间 (秒) 内只通知一次，默认 300，-1 表示不限制
+	RateLimitSec int               `mapstructure:"rate_limit_sec"`
+	Webhooks     []WebhookNotifier `mapstructure:"webhooks"`
+	Exec         []ExecNotifier    `mapstructure:"exec"`
+}
+
+// WebhookNotifier 通过 HTTP POST 发送通知。
+type WebhookNotifier struct {
+	URL    string   `mapstructure:"url"`
+	Format string   `mapstructure:"format"` // json (默认) / slack / feishu / dingtalk
+	Events []string `mapstructure:"events"` // 只通知这些事件，为空表示全部
+}
+
+// ExecNotifier 通过执行命令发送通知，事件详情通过环境变量和标准输入 (JSON) 传入。
+type ExecNotifier struct {
+	Command string   `mapstructure:"command"`
+	Events  []string `mapstructure:"events"` // 只通知这些事件，为空表示全部
 }
 
 // Process 结构体对应 'processes' 列表中的每一个进程项。
@@ -30,8 +70,15 @@ type Process struct {
 	Command string `mapstructure:"command"`
 	WorkDir string `mapstructure:"workdir"`
 	Port    int    `mapstructure:"port"`
+	Host    string `mapstructure:"host"` // 进程监听 port 的地址，为空时监听在任意地址上均可
 	Enabled bool   `mapstructure:"enabled"`
 
+	// 执行方式
+	// - args: 参数列表，通过 PATH 查找后直接执行，不经过 shell (与 command 二选一)
+	// - shell: 执行 command 使用的 shell，可选 bash (默认) / sh / none (按空白拆分后直接执行)
+	Args  []string `mapstructure:"args"`
+	Shell string   `mapstructure:"shell"`
+
 	// 使用 int 表示超时（秒）
 	// 如果 YAML 中未配置，将使用全局默认值。
 	StartTimeoutSec int `mapstructure:"start_timeout_sec"`
@@ -45,6 +92,65 @@ type Process struct {
 
 	// 额外的日志文件路径 (用于Java应用等使用日志框架的情况)
 	LogFiles []string `mapstructure:"log_files"`
+
+	// 托管日志 (stdout/stderr) 配置
+	// - stdout_log / stderr_log: 自定义日志路径，配置了 stderr_log 时 stderr 单独记录
+	// - merge_stderr: 是否将 stderr 合并到 stdout 日志，默认合并 (配置了 stderr_log 时默认不合并)
+	// - log_options: 覆盖全局的日志轮转配置
+	// - disable_log: 不记录 stdout/stderr
+	StdoutLog   string             `mapstructure:"stdout_log"`
+	StderrLog   string             `mapstructure:"stderr_log"`
+	MergeStderr *bool              `mapstructure:"merge_stderr"`
+	LogOptions  *ProcessLogOptions `mapstructure:"log_options"`
+	DisableLog  bool               `mapstructure:"disable_log"`
+
+	// 日志行处理
+	// - log_timestamps: 为每一行加上 RFC3339 时间戳、输出流 (stdout/stderr) 和进程名
+	// - log_format: plain (默认) 或 json (每行一个 JSON 对象，始终包含时间戳)
+	LogTimestamps bool   `mapstructure:"log_timestamps"`
+	LogFormat     string `mapstructure:"log_format"`
+
+	// 运行身份 (为空表示沿用 procmate 自身的身份)
+	// 注意 'group' 已用于进程分组，因此系统用户组使用 'run_group'
+	User                string   `mapstructure:"user"`
+	RunGroup            string   `mapstructure:"run_group"`
+	SupplementaryGroups []string `mapstructure:"supplementary_groups"`
+	Umask               string   `mapstructure:"umask"` // 八进制字符串，例如 "027"
+
+	// 资源限制 (rlimit 以及可选的 cgroup v2)
+	Limits Limits `mapstructure:"limits"`
+
+	// 实例数量 (大于 0 时展开为 name-0..name-N-1 多个实例)
+	Instances int `mapstructure:"instances"`
+
+	// 定时任务：配置了 schedule 的进程不常驻，由 watch 按计划启动，不做就绪检查和自动重启
+	// - schedule: 标准 5 段 cron 表达式 (分 时 日 月 周)，也支持 @hourly、@every 10m 等写法
+	// - overlap: 上次运行尚未结束时的处理方式，skip (默认，跳过本次) / queue (等上次结束后运行) / kill-previous (停止上次运行)
+	// - jitter_sec: 每次运行前随机延迟 0 ~ jitter_sec 秒，避免多个任务同时启动
+	Schedule  string `mapstructure:"schedule"`
+	Overlap   string `mapstructure:"overlap"`
+	JitterSec int    `mapstructure:"jitter_sec"`
+
+	// 以下字段由展开逻辑填充，不从配置文件读取
+	InstanceOf string `mapstructure:"-"` // 所属的原始进程名，为空表示非多实例进程
+	Instance   int    `mapstructure:"-"` // 实例序号，从 0 开始
+}
+
+// Limits 结构体对应进程的 'limits' 部分。
+// 所有字段均为字符串，留空表示不限制；支持 "unlimited"，
+// 容量类字段支持 K/M/G 后缀 (例如 "512M")，不带后缀时单位为字节。
+type Limits struct {
+	// rlimit，在执行命令之前设置
+	Nofile  string `mapstructure:"nofile"`  // 最大打开文件数
+	Nproc   string `mapstructure:"nproc"`   // 最大进程数
+	Core    string `mapstructure:"core"`    // core 文件大小
+	Memlock string `mapstructure:"memlock"` // 锁定内存大小
+	AS      string `mapstructure:"as"`      // 虚拟地址空间大小
+
+	// cgroup v2，仅在 Linux 且 cgroup 层级可写时生效
+	MemoryMax string `mapstructure:"memory_max"` // 内存上限，例如 "512M"
+	CPUMax    string `mapstructure:"cpu_max"`    // CPU 上限，例如 "50%" 或原生格式 "50000 100000"
+	PidsMax   string `mapstructure:"pids_max"`   // 最大任务数
 }
 
 // LogOptions 结构体对应 'log_options' 部分，用于配置日志轮转。
@@ -56,9 +162,38 @@ type LogOptions struct {
 	LocalTime  bool `mapstructure:"localTime"`
 }
 
+// ProcessLogOptions 是进程级的日志轮转配置，未配置的字段沿用全局 'log_options'。
+type ProcessLogOptions struct {
+	MaxSizeMB  int   `mapstructure:"max_size_mb"`
+	MaxBackups int   `mapstructure:"max_backups"`
+	MaxAgeDays int   `mapstructure:"max_age_days"`
+	Compress   *bool `mapstructure:"compress"`
+	LocalTime  *bool `mapstructure:"localTime"`
+}
+
 // Cfg 是一个指向 Config 实例的全局指针，用于在程序各处访问配置。
+// 配置只会被整体替换 (LoadConfig / Scale)，已发布的 Config 不会被原地修改。
+// 读取配置一律通过 Current，以免与 watch 重新加载配置并发执行时发生数据竞争。
 var Cfg *Config
 
+// cfgMu 保护 Cfg 和 templates 的替换
+var cfgMu sync.RWMutex
+
+// Current 返回当前配置的快照，可在重新加载配置的同时安全使用。
+func Current() *Config {
+	cfgMu.RLock()
+	defer cfgMu.RUnlock()
+	return Cfg
+}
+
+// publish 以 cfg 替换全局配置。
+func publish(cfg *Config, procTemplates []Process) {
+	cfgMu.Lock()
+	defer cfgMu.Unlock()
+	Cfg = cfg
+	templates = procTemplates
+}
+
 // LoadConfig 使用 Viper 读取和解析配置文件。
 // 最新版本支持 'include' 指令，并能处理重名进程（后来者覆盖）并发出警告。
 func LoadConfig(path string) error {
@@ -69,8 +204,9 @@ func LoadConfig(path string) error {
 	if err := v.ReadInConfig(); err != nil {
 		return fmt.Errorf("failed to read main config: %w", err)
 	}
-	// 主配置反序列化入全局变量
-	if err := v.Unmarshal(&Cfg); err != nil {
+	// 主配置反序列化到新的实例，全部成功后再替换全局变量 (重新加载失败时保留原配置)
+	cfg := &Config{}
+	if err := v.Unmarshal(cfg); err != nil {
 		return fmt.Errorf("failed to unmarshal main config: %w", err)
 	}
 
@@ -81,7 +217,7 @@ func LoadConfig(path string) error {
 	process := func(procs []Process, sourceFile string) {
 		for _, p := range procs {
 			if index, exists := seenProcs[p.Name]; exists {
-				fmt.Printf("Warning: Duplicate process '%s' in %s overwrites previous definition.\n", p.Name, sourceFile)
+				fmt.Fprintf(os.Stderr, "Warning: Duplicate process '%s' in %s overwrites previous definition.\n", p.Name, sourceFile)
 				finalProcesses[index] = p // 覆盖
 			} else {
 				finalProcesses = append(finalProcesses, p) // 追加
@@ -91,11 +227,11 @@ func LoadConfig(path string) error {
 	}
 
 	//  处理主配置文件中的进程
-	process(Cfg.Processes, path)
+	process(cfg.Processes, path)
 
 	//  处理 include 文件
-	if Cfg.Include != "" {
-		globPath := filepath.Join(filepath.Dir(path), Cfg.Include)
+	if cfg.Include != "" {
+		globPath := filepath.Join(filepath.Dir(path), cfg.Include)
 		if files, err := filepath.Glob(globPath); err == nil {
 			// 循环子配置文件
 			for _, file := range files {
@@ -113,7 +249,13 @@ func LoadConfig(path string) error {
 		}
 	}
 
-	// 3. 将最终结果赋回全局配置
-	Cfg.Processes = finalProcesses
+	if err := validate(cfg, finalProcesses); err != nil {
+		return err
+	}
+
+	// 3. 展开多实例进程，并将最终结果赋回全局配置
+	// (实例数量的覆盖值保存在新配置的 runtime_dir 中)
+	cfg.Processes = expandInstances(finalProcesses, loadScaleOverrides(runtimeDirOf(cfg)))
+	publish(cfg, finalProcesses)
 	return nil
 }
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultRuntimeDir 是未配置 runtime_dir 时使用的运行时目录。
+const DefaultRuntimeDir = "/tmp/procmate"
+
+// instanceVar 是命令、工作目录、环境变量等字段中可用的实例序号占位符。
+const instanceVar = "${instance}"
+
+// templates 保存展开前的进程定义，供 scale 重新展开时使用。
+var templates []Process
+
+// RuntimeDir 返回配置的运行时目录，未配置时返回默认值。
+func RuntimeDir() string {
+	return runtimeDirOf(Current())
+}
+
+// UseRuntimeDir 以 runtime_dir 改为 runtimeDir、日志也写入其中的配置副本替换当前配置。
+// procmate run 使用临时的运行时目录，与守护进程互不干扰。
+func UseRuntimeDir(runtimeDir string) {
+	cfgMu.Lock()
+	defer cfgMu.Unlock()
+	next := *Cfg
+	next.Settings.RuntimeDir = runtimeDir
+	next.Settings.LogDir = ""
+	Cfg = &next
+}
+
+// runtimeDirOf 返回 cfg 的运行时目录，未配置时返回默认值。
+func runtimeDirOf(cfg *Config) string {
+	if cfg != nil && cfg.Settings.RuntimeDir != "" {
+		return cfg.Settings.RuntimeDir
+	}
+	return DefaultRuntimeDir
+}
+
+// scaleFile 返回持久化实例数量覆盖值的文件路径。
+// 格式：<runtime_dir>/scale.json
+func scaleFile(runtimeDir string) string {
+	return filepath.Join(runtimeDir, "scale.json")
+}
+
+// loadScaleOverrides 读取 'procmate scale' 持久化的实例数量。
+// 文件不存在或内容损坏时返回空映射，即使用配置文件中的 instances。
+func loadScaleOverrides(runtimeDir string) map[string]int {
+	overrides := make(map[string]int)
+	data, err := os.ReadFile(scaleFile(runtimeDir))
+	if err != nil {
+		return overrides
+	}
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring corrupted scale file %s: %v\n", scaleFile(runtimeDir), err)
+		return make(map[string]int)
+	}
+	return overrides
+}
+
+// expandInstances 将配置了 instances 的进程展开为多个独立实例。
+// - 实例名称为 <name>-<序号>，因此每个实例拥有独立的 PID 文件和日志。
+// - ${instance} 占位符会被替换为实例序号 (command、args、workdir、environment、log_files、stdout_log、stderr_log)。
+// - 配置了端口时，每个实例的端口依次偏移 (port + 序号)。
+// - 其他进程对原始名称的依赖会被展开为对所有实例的依赖。
+func expandInstances(procs []Process, overrides map[string]int) []Process {
+	expanded := make([]Process, 0, len(procs))
+	instanceNames := make(map[string][]string) // K: 原始进程名, V: 所有实例名
+
+	for _, p := range procs {
+		count := p.Instances
+		if n, ok := overrides[p.Name]; ok && p.Instances > 0 {
+			count = n
+		}
+		if p.Instances <= 0 {
+			expanded = append(expanded, p)
+			continue
+		}
+
+		names := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			inst := newInstance(p, i)
+			expanded = append(expanded, inst)
+			names = append(names, inst.Name)
+		}
+		instanceNames[p.Name] = names
+	}
+
+	// 将对多实例进程的依赖替换为对其所有实例的依赖
+	for i := range expanded {
+		if len(expanded[i].DependsOn) == 0 {
+			continue
+		}
+		var deps []string
+		for _, dep := range expanded[i].DependsOn {
+			if names, ok := instanceNames[dep]; ok {
+				deps = append(deps, names...)
+			} else {
+				deps = append(deps, dep)
+			}
+		}
+		expanded[i].DependsOn = deps
+	}
+
+	return expanded
+}
+
+// newInstance 基于模板创建第 index 个实例。
+func newInstance(tmpl Process, index int) Process {
+	idx := strconv.Itoa(index)
+	replace := func(s string) string {
+		return strings.ReplaceAll(s, instanceVar, idx)
+	}
+
+	inst := tmpl
+	inst.Name = fmt.Sprintf("%s-%d", tmpl.Name, index)
+	inst.InstanceOf = tmpl.Name
+	inst.Instance = index
+	inst.Command = replace(tmpl.Command)
+	inst.WorkDir = replace(tmpl.WorkDir)
+	inst.StdoutLog = replace(tmpl.StdoutLog)
+	inst.StderrLog = replace(tmpl.StderrLog)
+	if tmpl.Port > 0 {
+		inst.Port = tmpl.Port + index
+	}
+
+	// map 和切片需要深拷贝，避免实例之间共享底层数据
+	if tmpl.Environment != nil {
+		inst.Environment = make(map[string]string, len(tmpl.Environment))
+		for k, v := range tmpl.Environment {
+			inst.Environment[k] = replace(v)
+		}
+	}
+	if tmpl.LogFiles != nil {
+		inst.LogFiles = make([]string, len(tmpl.LogFiles))
+		for i, f := range tmpl.LogFiles {
+			inst.LogFiles[i] = replace(f)
+		}
+	}
+	if tmpl.Args != nil {
+		inst.Args = make([]string, len(tmpl.Args))
+		for i, a := range tmpl.Args {
+			inst.Args[i] = replace(a)
+		}
+	}
+	if tmpl.DependsOn != nil {
+		inst.DependsOn = append([]string(nil), tmpl.DependsOn...)
+	}
+	return inst
+}
+
+// Scale 调整多实例进程的实例数量，并将结果持久化到运行时目录。
+// 返回调整前后该进程的所有实例，调用方据此启动新增实例、停止多余实例。
+func Scale(name string, count int) (before []Process, after []Process, err error) {
+	if count < 0 {
+		return nil, nil, fmt.Errorf("实例数量不能为负数: %d", count)
+	}
+
+	// 整个调整期间持有写锁，templates 与当前配置保持一致，也不会与重新加载交错
+	cfgMu.Lock()
+	defer cfgMu.Unlock()
+
+	var tmpl *Process
+	for i := range templates {
+		if templates[i].Name == name {
+			tmpl = &templates[i]
+			break
+		}
+	}
+	if tmpl == nil {
+		return nil, nil, fmt.Errorf("在配置文件中未找到名为 '%s' 的进程", name)
+	}
+	if tmpl.Instances <= 0 {
+		return nil, nil, fmt.Errorf("进程 '%s' 未配置 instances，无法调整实例数量 (可先在配置中设置 instances: 1)", name)
+	}
+
+	current := Cfg
+	for _, p := range current.Processes {
+		if p.InstanceOf == name {
+			before = append(before, p)
+		}
+	}
+
+	// 持久化覆盖值
+	runtimeDir := runtimeDirOf(current)
+	overrides := loadScaleOverrides(runtimeDir)
+	overrides[name] = count
+	data, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(runtimeDir, 0755); err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(scaleFile(runtimeDir), data, 0644); err != nil {
+		return nil, nil, fmt.Errorf("写入 %s 失败: %w", scaleFile(runtimeDir), err)
+	}
+
+	// 重新展开，以新的配置替换全局配置 (已发布的配置不会被原地修改)
+	next := *current
+	next.Processes = expandInstances(templates, overrides)
+	Cfg = &next
+	for _, p := range next.Processes {
+		if p.InstanceOf == name {
+			after = append(after, p)
+		}
+	}
+	return before, after, nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExpandInstancesSubstitutesLogPaths(t *testing.T) {
+	tmpl := Process{
+		Name:      "worker",
+		Command:   "./worker --id ${instance}",
+		WorkDir:   "/srv/worker-${instance}",
+		LogFiles:  []string{"/var/log/worker-${instance}.log"},
+		StdoutLog: "/var/log/worker-${instance}.out",
+		StderrLog: "/var/log/worker-${instance}.err",
+		Instances: 2,
+	}
+
+	procs := expandInstances([]Process{tmpl}, nil)
+	if len(procs) != 2 {
+		t.Fatalf("展开得到 %d 个实例，期望 2 个", len(procs))
+	}
+	for i, p := range procs {
+		want := map[string]string{
+			"command":    fmt.Sprintf("./worker --id %d", i),
+			"workdir":    fmt.Sprintf("/srv/worker-%d", i),
+			"log_files":  fmt.Sprintf("/var/log/worker-%d.log", i),
+			"stdout_log": fmt.Sprintf("/var/log/worker-%d.out", i),
+			"stderr_log": fmt.Sprintf("/var/log/worker-%d.err", i),
+		}
+		got := map[string]string{
+			"command":    p.Command,
+			"workdir":    p.WorkDir,
+			"log_files":  p.LogFiles[0],
+			"stdout_log": p.StdoutLog,
+			"stderr_log": p.StderrLog,
+		}
+		for field, w := range want {
+			if got[field] != w {
+				t.Errorf("实例 %s 的 %s 为 %q，期望 %q", p.Name, field, got[field], w)
+			}
+		}
+	}
+}
+
+func TestValidateRejectsSharedOutputLog(t *testing.T) {
+	tmpl := Process{Name: "worker", StdoutLog: "/var/log/worker.out", Instances: 2}
+	err := validate(&Config{}, expandInstances([]Process{tmpl}, nil))
+	if err == nil || !strings.Contains(err.Error(), "${instance}") {
+		t.Errorf("多个实例共用 stdout_log 时应报错并提示 ${instance}，得到 %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/robfig/cron/v3"
+)
+
+// validate 检查配置中无法在启动进程前发现的取值错误，避免错误的配置在运行时被静默忽略。
+func validate(cfg *Config, procs []Process) error {
+	for i, w := range cfg.Settings.Notifications.Webhooks {
+		switch w.Format {
+		case "", "json", "slack", "feishu", "dingtalk":
+		default:
+			return fmt.Errorf("notifications.webhooks[%d] 的 format '%s' 无效，可选 json / slack / feishu / dingtalk", i, w.Format)
+		}
+	}
+	// 多个进程写入同一个输出日志会互相混杂，基于日志的就绪检查也会把其他进程的输出当作就绪信号
+	logOwners := make(map[string]string)
+	for _, p := range procs {
+		for _, path := range []string{p.StdoutLog, p.StderrLog} {
+			if path == "" {
+				continue
+			}
+			if owner, ok := logOwners[path]; ok && owner != p.Name {
+				hint := ""
+				if p.InstanceOf != "" {
+					hint = "，多实例进程可在路径中使用 ${instance}"
+				}
+				return fmt.Errorf("进程 '%s' 与 '%s' 使用了相同的输出日志 %s%s", owner, p.Name, path, hint)
+			}
+			logOwners[path] = p.Name
+		}
+	}
+
+	for _, p := range procs {
+		if p.Schedule != "" {
+			if _, err := cron.ParseStandard(p.Schedule); err != nil {
+				return fmt.Errorf("进程 '%s' 的 schedule '%s' 无效: %w", p.Name, p.Schedule, err)
+			}
+		}
+		switch p.Overlap {
+		case "", "skip", "queue", "kill-previous":
+		default:
+			return fmt.Errorf("进程 '%s' 的 overlap '%s' 无效，可选 skip / queue / kill-previous", p.Name, p.Overlap)
+		}
+		if p.Limits.CPUMax != "" {
+			if _, err := ParseCPUMax(p.Limits.CPUMax); err != nil {
+				return fmt.Errorf("进程 '%s' 的 limits.cpu_max 无效: %w", p.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseCPUMax 将 cpu_max 配置转换为 cgroup v2 cpu.max 的格式 "<quota|max> <period>"。
+// 支持百分比 ("150%" 表示 1.5 个核)、"unlimited" 和原生格式 ("150000 100000" / "150000" / "max")。
+// 周期默认为 100000 微秒，取值范围与内核一致 (1000 ~ 1000000)。
+func ParseCPUMax(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "unlimited" {
+		return "max 100000", nil
+	}
+	if strings.HasSuffix(s, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || percent <= 0 {
+			return "", fmt.Errorf("无效的 CPU 百分比 '%s'", s)
+		}
+		quota := int64(percent * 1000)
+		if quota < 1000 {
+			return "", fmt.Errorf("CPU 百分比 '%s' 过小，至少为 1%%", s)
+		}
+		return fmt.Sprintf("%d 100000", quota), nil
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", fmt.Errorf("无效的取值 '%s'，应为百分比 (例如 50%%) 或 \"<quota|max> [period]\"", s)
+	}
+	period := int64(100000)
+	if len(fields) == 2 {
+		n, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || n < 1000 || n > 1000000 {
+			return "", fmt.Errorf("无效的周期 '%s'，应为 1000 ~ 1000000 之间的微秒数", fields[1])
+		}
+		period = n
+	}
+	if fields[0] == "max" {
+		return fmt.Sprintf("max %d", period), nil
+	}
+	quota, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || quota < 1000 {
+		return "", fmt.Errorf("无效的配额 '%s'，应为不小于 1000 的微秒数或 max", fields[0])
+	}
+	return fmt.Sprintf("%d %d", quota, period), nil
+}
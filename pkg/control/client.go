@@ -0,0 +1,140 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	"procmate/pkg/process"
+)
+
+// queryTimeout 是查询类请求 (状态、重新加载) 的超时时间
+const queryTimeout = 30 * time.Second
+
+// actionTimeout 是启动、停止、重启请求的超时时间，需要留出逐层等待进程就绪或退出的时间
+const actionTimeout = 10 * time.Minute
+
+// Client 通过 Unix socket 访问守护进程的控制接口。
+type Client struct {
+	http *http.Client
+}
+
+// Connect 尝试连接 runtime_dir 中的守护进程，守护进程未运行时返回 nil。
+// socket 存在但因其他原因 (如没有权限) 无法连接时返回错误，调用方不能绕过守护进程自行操作进程。
+func Connect(runtimeDir string) (*Client, error) {
+	socketPath := SocketPath(runtimeDir)
+	if _, err := os.Stat(socketPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("无法访问控制接口 %s: %w", socketPath, err)
+	}
+	conn, err := net.DialTimeout("unix", socketPath, 500*time.Millisecond)
+	if err != nil {
+		// 连接被拒绝或 socket 已被删除：socket 文件是守护进程异常退出后留下的
+		if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENOENT) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("watch 守护进程正在运行，但无法连接其控制接口 %s: %w", socketPath, err)
+	}
+	conn.Close()
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+	return &Client{http: &http.Client{Transport: transport}}, nil
+}
+
+// Processes 返回所有已启用进程的状态。
+func (c *Client) Processes() ([]process.ProcessInfo, error) {
+	var resp ProcessesResponse
+	if err := c.do(http.MethodGet, "/v1/processes", nil, &resp, queryTimeout); err != nil {
+		return nil, err
+	}
+	return resp.Processes, nil
+}
+
+// Start 请求守护进程启动进程 (包括其依赖)。
+func (c *Client) Start(targets []string, forceFreePort bool) (*ActionResponse, error) {
+	return c.action("start", TargetsRequest{Targets: targets, ForceFreePort: forceFreePort})
+}
+
+// Stop 请求守护进程停止进程 (包括其依赖)。
+func (c *Client) Stop(targets []string) (*ActionResponse, error) {
+	return c.action("stop", TargetsRequest{Targets: targets})
+}
+
+// StopOnly 请求守护进程只停止 targets 本身，不包括其依赖。
+func (c *Client) StopOnly(targets []string) (*ActionResponse, error) {
+	return c.action("stop", TargetsRequest{Targets: targets, NoDeps: true})
+}
+
+// Restart 请求守护进程重启进程。
+func (c *Client) Restart(targets []string, forceFreePort bool) (*ActionResponse, error) {
+	return c.action("restart", TargetsRequest{Targets: targets, ForceFreePort: forceFreePort})
+}
+
+// Reload 请求守护进程重新加载配置文件。
+func (c *Client) Reload() (*ReloadResponse, error) {
+	var resp ReloadResponse
+	if err := c.do(http.MethodPost, "/v1/reload", nil, &resp, queryTimeout); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) action(name string, req TargetsRequest) (*ActionResponse, error) {
+	var resp ActionResponse
+	if err := c.do(http.MethodPost, "/v1/"+name, req, &resp, actionTimeout); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do 发送请求并解析 JSON 响应，守护进程在 timeout 内没有完成响应时返回错误。
+func (c *Client) do(method, path string, body, out any, timeout time.Duration) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// 主机名只是占位，实际连接的是 Unix socket
+	req, err := http.NewRequestWithContext(ctx, method, "http://procmate"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求守护进程失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+			return fmt.Errorf("守护进程返回错误: %s", e.Error)
+		}
+		return fmt.Errorf("守护进程返回错误: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,70 @@
+// Package control 实现 watch 守护进程的控制接口。
+//
+// 守护进程运行时，在 runtime_dir 中监听一个 Unix socket (也可以额外监听 TCP)，
+// 通过 HTTP + JSON 提供查询和操作进程的接口。命令行在守护进程运行时通过该接口发起操作，
+// 由守护进程统一修改进程状态，避免多个 procmate 命令同时读写 PID 文件。
+//
+// 接口列表：
+//
+//	GET  /v1/processes          所有已启用进程的状态
+//	GET  /v1/processes/{name}   单个进程的状态
+//	POST /v1/start              启动进程，请求体为 {"targets": [...]}
+//	POST /v1/stop               停止进程
+//	POST /v1/restart            重启进程
+//	POST /v1/reload             重新加载配置文件
+//	GET  /v1/logs               日志流 (每行一个 JSON 对象)，参数: target (可重复)、lines、since、follow、grep、level
+package control
+
+import (
+	"path/filepath"
+
+	"procmate/pkg/process"
+)
+
+// SocketName 是控制接口的 Unix socket 在 runtime_dir 中的文件名。
+const SocketName = "procmate.sock"
+
+// SocketPath 返回控制接口 Unix socket 的路径。
+func SocketPath(runtimeDir string) string {
+	return filepath.Join(runtimeDir, SocketName)
+}
+
+// TargetsRequest 是启动、停止、重启接口的请求体。
+// targets 的写法与命令行相同：进程名、多实例进程的原始名称、@group 或 all。
+type TargetsRequest struct {
+	Targets []string `json:"targets"`
+	NoDeps  bool     `json:"no_deps,omitempty"` // 仅用于停止：只停止 targets 本身，不包括其依赖
+
+	ForceFreePort bool `json:"force_free_port,omitempty"` // 仅用于启动和重启：端口被残留进程占用时先停止它
+}
+
+// ActionResult 是单个进程的操作结果。
+type ActionResult struct {
+	Process     string  `json:"process"`
+	Success     bool    `json:"success"`
+	Skipped     bool    `json:"skipped,omitempty"` // 已在运行 (启动时) 或未在运行 (停止时)
+	Error       string  `json:"error,omitempty"`
+	DurationSec float64 `json:"duration_sec"`
+}
+
+// ActionResponse 是启动、停止、重启接口的响应。
+type ActionResponse struct {
+	Results []ActionResult `json:"results"`
+	Invalid []string       `json:"invalid,omitempty"` // 无法识别或未启用的名称
+}
+
+// ProcessesResponse 是进程状态接口的响应。
+type ProcessesResponse struct {
+	Processes []process.ProcessInfo `json:"processes"`
+}
+
+// ReloadResponse 是重新加载配置接口的响应。
+type ReloadResponse struct {
+	Added   []string `json:"added,omitempty"`   // 新增的进程
+	Removed []string `json:"removed,omitempty"` // 被移除的进程 (不会被自动停止)
+}
+
+// errorResponse 是接口出错时的响应。
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,248 @@
+package control
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+
+	"procmate/pkg/process"
+)
+
+// Backend 是控制接口背后的实际操作，由守护进程实现。
+// 所有会修改进程状态的方法都应与守护进程的巡检互斥执行。
+type Backend interface {
+	Processes() ([]process.ProcessInfo, error)
+	Start(req TargetsRequest) (*ActionResponse, error)
+	Stop(req TargetsRequest) (*ActionResponse, error)
+	Restart(req TargetsRequest) (*ActionResponse, error)
+	Reload() (*ReloadResponse, error)
+	Logs(ctx context.Context, targets []string, opts process.TailOptions, fn func(process.LogLine)) error
+}
+
+// Server 是正在运行的控制接口。
+type Server struct {
+	servers    []*http.Server
+	socketPath string
+}
+
+// Serve 在 socketPath 上 (以及 tcpAddr 非空时在该 TCP 地址上) 提供控制接口。
+// TCP 上的请求需要携带 "Authorization: Bearer <token>"，未配置 token 时拒绝监听 TCP；
+// Unix socket 依靠文件权限控制访问。
+func Serve(backend Backend, socketPath, tcpAddr, token string) (*Server, error) {
+	if tcpAddr != "" && token == "" {
+		return nil, fmt.Errorf("监听 TCP 地址 %s 时必须配置 control_token", tcpAddr)
+	}
+
+	// 清理上次异常退出留下的 socket 文件，但不能影响正在运行的守护进程
+	if _, err := os.Stat(socketPath); err == nil {
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("已有守护进程在监听 %s", socketPath)
+		}
+		os.Remove(socketPath)
+	}
+
+	unixListener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("监听 %s 失败: %w", socketPath, err)
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		unixListener.Close()
+		return nil, fmt.Errorf("设置 %s 的权限失败: %w", socketPath, err)
+	}
+
+	handler := newHandler(backend)
+	s := &Server{socketPath: socketPath}
+	s.serve(unixListener, handler)
+
+	if tcpAddr != "" {
+		tcpListener, err := net.Listen("tcp", tcpAddr)
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("监听 %s 失败: %w", tcpAddr, err)
+		}
+		s.serve(tcpListener, requireToken(handler, token))
+	}
+	return s, nil
+}
+
+// serve 在 listener 上启动一个 HTTP 服务。
+func (s *Server) serve(ln net.Listener, handler http.Handler) {
+	server := &http.Server{Handler: handler}
+	s.servers = append(s.servers, server)
+	go server.Serve(ln)
+}
+
+// Close 关闭控制接口并删除 socket 文件。
+func (s *Server) Close() {
+	for _, server := range s.servers {
+		server.Close()
+	}
+	os.Remove(s.socketPath)
+}
+
+// requireToken 校验请求携带的 token。
+func requireToken(next http.Handler, token string) http.Handler {
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("未授权"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newHandler 注册所有接口。
+func newHandler(backend Backend) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /v1/processes", func(w http.ResponseWriter, r *http.Request) {
+		infos, err := backend.Processes()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, ProcessesResponse{Processes: infos})
+	})
+
+	mux.HandleFunc("GET /v1/processes/{name}", func(w http.ResponseWriter, r *http.Request) {
+		infos, err := backend.Processes()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		name := r.PathValue("name")
+		for _, info := range infos {
+			if info.Name == name {
+				writeJSON(w, info)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, fmt.Errorf("未找到名为 '%s' 的已启用进程", name))
+	})
+
+	actions := map[string]func(TargetsRequest) (*ActionResponse, error){
+		"start":   backend.Start,
+		"stop":    backend.Stop,
+		"restart": backend.Restart,
+	}
+	for name, action := range actions {
+		mux.HandleFunc("POST /v1/"+name, func(w http.ResponseWriter, r *http.Request) {
+			var req TargetsRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("解析请求失败: %w", err))
+				return
+			}
+			if len(req.Targets) == 0 {
+				writeError(w, http.StatusBadRequest, errors.New("targets 不能为空"))
+				return
+			}
+			resp, err := action(req)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			writeJSON(w, resp)
+		})
+	}
+
+	mux.HandleFunc("POST /v1/reload", func(w http.ResponseWriter, r *http.Request) {
+		resp, err := backend.Reload()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, resp)
+	})
+
+	mux.HandleFunc("GET /v1/logs", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		targets := query["target"]
+		if len(targets) == 0 {
+			writeError(w, http.StatusBadRequest, errors.New("至少需要一个 target 参数"))
+			return
+		}
+		opts, err := parseTailOptions(query.Get)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		flusher, _ := w.(http.Flusher)
+		enc := json.NewEncoder(w)
+		err = backend.Logs(r.Context(), targets, opts, func(line process.LogLine) {
+			enc.Encode(line)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		})
+		if err != nil {
+			enc.Encode(errorResponse{Error: err.Error()})
+		}
+	})
+
+	return mux
+}
+
+// parseTailOptions 从查询参数解析日志选项。
+func parseTailOptions(get func(string) string) (process.TailOptions, error) {
+	var opts process.TailOptions
+	if v := get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("lines 参数无效: %w", err)
+		}
+		opts.Lines = n
+	}
+	if v := get("since"); v != "" {
+		since, err := process.ParseSince(v)
+		if err != nil {
+			return opts, err
+		}
+		opts.Since = since
+	}
+	if v := get("follow"); v != "" {
+		follow, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("follow 参数无效: %w", err)
+		}
+		opts.Follow = follow
+	}
+	if v := get("grep"); v != "" {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return opts, fmt.Errorf("grep 参数无效: %w", err)
+		}
+		opts.Grep = re
+	}
+	if v := get("level"); v != "" {
+		level, err := process.ParseLevel(v)
+		if err != nil {
+			return opts, err
+		}
+		opts.Level = level
+	}
+	return opts, nil
+}
+
+// writeJSON 输出 JSON 响应。
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 输出错误响应。
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,249 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"procmate/pkg/config"
+	"procmate/pkg/process"
+)
+
+// 可通知的事件，与事件日志中的类型一致 (restart_failed 除外)
+const (
+	EventOffline       = process.EventOffline     // watch 发现进程离线
+	EventProbeFailed   = process.EventProbeFailed // 进程运行超过启动超时时间仍未就绪
+	EventRestart       = process.EventRestart     // watch 自动重启进程成功
+	EventRestartFailed = "restart_failed"         // watch 自动重启进程失败，需要人工处理
+)
+
+// defaultRateLimit 是同一通知渠道对同一进程的同一类事件的默认最小通知间隔
+const defaultRateLimit = 5 * time.Minute
+
+// sendTimeout 是单次发送 (HTTP 请求或执行命令) 的超时时间
+const sendTimeout = 10 * time.Second
+
+// Notification 是一次通知的内容，json 格式的 webhook 和 exec 的标准输入使用该结构。
+type Notification struct {
+	Event      string    `json:"event"`
+	Process    string    `json:"process"`
+	Message    string    `json:"message"`
+	Time       time.Time `json:"time"`
+	Host       string    `json:"host"`
+	Suppressed int       `json:"suppressed,omitempty"` // 上次通知之后因频率限制未发送的次数
+}
+
+// Text 返回通知的文本形式，用于 Slack / 飞书 / 钉钉等聊天工具。
+func (n Notification) Text() string {
+	icons := map[string]string{
+		EventOffline:       "🚨",
+		EventProbeFailed:   "⏱️",
+		EventRestart:       "🔁",
+		EventRestartFailed: "❌",
+	}
+	text := fmt.Sprintf("%s [procmate@%s] %s: %s", icons[n.Event], n.Host, n.Process, n.Message)
+	if n.Suppressed > 0 {
+		text += fmt.Sprintf(" (此前另有 %d 次同类通知被抑制)", n.Suppressed)
+	}
+	return text
+}
+
+// limitState 是一个 (通知渠道, 进程, 事件) 的频率限制状态。
+type limitState struct {
+	last       time.Time
+	suppressed int
+}
+
+var (
+	limitMu sync.Mutex
+	limits  = make(map[string]*limitState)
+)
+
+// allow 判断是否可以发送，不能发送时计入被抑制的次数。可以发送时返回此前被抑制的次数。
+func allow(key string, interval time.Duration, now time.Time) (bool, int) {
+	limitMu.Lock()
+	defer limitMu.Unlock()
+	s := limits[key]
+	if s == nil {
+		s = &limitState{}
+		limits[key] = s
+	}
+	if interval > 0 && !s.last.IsZero() && now.Sub(s.last) < interval {
+		s.suppressed++
+		return false, 0
+	}
+	suppressed := s.suppressed
+	s.last = now
+	s.suppressed = 0
+	return true, suppressed
+}
+
+// rateLimit 返回配置的最小通知间隔，0 表示不限制。
+func rateLimit(cfg config.Notifications) time.Duration {
+	switch {
+	case cfg.RateLimitSec < 0:
+		return 0
+	case cfg.RateLimitSec == 0:
+		return defaultRateLimit
+	default:
+		return time.Duration(cfg.RateLimitSec) * time.Second
+	}
+}
+
+// wants 判断通知渠道是否订阅了该事件。
+func wants(events []string, event string) bool {
+	if len(events) == 0 {
+		return true
+	}
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Send 按配置向所有订阅了该事件的通知渠道发送通知。
+// 发送在后台进行，不会阻塞调用方；失败时只打印提示。
+func Send(cfg config.Notifications, event, processName, message string) {
+	n := Notification{Event: event, Process: processName, Message: message, Time: time.Now()}
+	n.Host, _ = os.Hostname()
+	interval := rateLimit(cfg)
+
+	for _, w := range cfg.Webhooks {
+		if !wants(w.Events, event) {
+			continue
+		}
+		ok, suppressed := allow("webhook:"+w.URL+"|"+processName+"|"+event, interval, n.Time)
+		if !ok {
+			continue
+		}
+		wn := n
+		wn.Suppressed = suppressed
+		go func(w config.WebhookNotifier) {
+			if err := sendWebhook(w, wn); err != nil {
+				fmt.Printf("⚠️ 发送通知到 %s 失败: %v\n", redactURL(w.URL), err)
+			}
+		}(w)
+	}
+
+	for _, e := range cfg.Exec {
+		if !wants(e.Events, event) {
+			continue
+		}
+		ok, suppressed := allow("exec:"+e.Command+"|"+processName+"|"+event, interval, n.Time)
+		if !ok {
+			continue
+		}
+		en := n
+		en.Suppressed = suppressed
+		go func(e config.ExecNotifier) {
+			if err := runExec(e, en); err != nil {
+				fmt.Printf("⚠️ 执行通知命令 '%s' 失败: %v\n", e.Command, err)
+			}
+		}(e)
+	}
+}
+
+// redactURL 只保留 webhook 地址的协议和主机，路径和查询参数中常带有访问凭据，不能打印到日志中。
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "webhook"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// webhookPayload 按 format 生成 webhook 的请求体。
+func webhookPayload(format string, n Notification) (any, error) {
+	switch format {
+	case "", "json":
+		return n, nil
+	case "slack":
+		return map[string]any{"text": n.Text()}, nil
+	case "feishu":
+		return map[string]any{"msg_type": "text", "content": map[string]string{"text": n.Text()}}, nil
+	case "dingtalk":
+		return map[string]any{"msgtype": "text", "text": map[string]string{"content": n.Text()}}, nil
+	default:
+		return nil, fmt.Errorf("不支持的 webhook 格式 '%s'，可选 json / slack / feishu / dingtalk", format)
+	}
+}
+
+// sendWebhook 以 JSON 形式 POST 通知。
+func sendWebhook(w config.WebhookNotifier, n Notification) error {
+	payload, err := webhookPayload(w.Format, n)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return withoutURL(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return withoutURL(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return nil
+}
+
+// withoutURL 去掉 *url.Error 中完整的请求地址，只保留底层错误。
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// runExec 执行通知命令，事件详情通过 PROCMATE_EVENT* 环境变量和标准输入 (JSON) 传入。
+func runExec(e config.ExecNotifier, n Notification) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", e.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", e.Command)
+	}
+	cmd.Env = append(os.Environ(),
+		"PROCMATE_EVENT="+n.Event,
+		"PROCMATE_EVENT_PROCESS="+n.Process,
+		"PROCMATE_EVENT_MESSAGE="+n.Message,
+		"PROCMATE_EVENT_TIME="+n.Time.Format(time.RFC3339),
+		"PROCMATE_EVENT_HOST="+n.Host,
+		"PROCMATE_EVENT_SUPPRESSED="+strconv.Itoa(n.Suppressed),
+	)
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+//go:build linux
+
+package process
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"procmate/pkg/config"
+)
+
+const (
+	cgroupRoot  = "/sys/fs/cgroup" // cgroup v2 统一层级的挂载点
+	cgroupSlice = "procmate.slice" // procmate 管理的所有 cgroup 的父节点
+)
+
+// cgroupPath 返回进程对应的 cgroup 目录。
+// 格式：/sys/fs/cgroup/procmate.slice/<runtime_dir 的哈希>-<proc.Name>
+// 不同 runtime_dir 的 procmate (如 procmate run 的临时目录与 watch 守护进程) 不会共用、误删同名进程的 cgroup。
+func cgroupPath(proc config.Process) string {
+	sum := sha256.Sum256([]byte(absRuntimeDir()))
+	return filepath.Join(cgroupRoot, cgroupSlice, fmt.Sprintf("%x-%s", sum[:4], proc.Name))
+}
+
+// setupCgroup 为进程创建 cgroup 并写入限制，然后让子进程在创建时直接加入该 cgroup。
+// - 未配置 cgroup 限制时不做任何事。
+// - 系统不是 cgroup v2 或层级不可写时打印警告并跳过，不影响进程启动。
+// - 层级可写但无法启用所配置限制需要的控制器时返回错误，避免限制被静默忽略。
+// 返回的 cleanup 函数需要在 cmd.Start() 之后调用，用于关闭 cgroup 目录句柄。
+func setupCgroup(cmd *exec.Cmd, proc config.Process) (func(), error) {
+	noop := func() {}
+	if !hasCgroupLimits(proc.Limits) {
+		return noop, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		fmt.Printf("⚠️ 系统未启用 cgroup v2，忽略进程 '%s' 的 cgroup 限制。\n", proc.Name)
+		return noop, nil
+	}
+
+	// === 创建 procmate.slice 并启用所需的控制器 ===
+	slice := filepath.Join(cgroupRoot, cgroupSlice)
+	if err := os.MkdirAll(slice, 0755); err != nil {
+		fmt.Printf("⚠️ cgroup 层级不可写 (%v)，忽略进程 '%s' 的 cgroup 限制。\n", err, proc.Name)
+		return noop, nil
+	}
+	var controllers []string
+	if proc.Limits.MemoryMax != "" {
+		controllers = append(controllers, "memory")
+	}
+	if proc.Limits.CPUMax != "" {
+		controllers = append(controllers, "cpu")
+	}
+	if proc.Limits.PidsMax != "" {
+		controllers = append(controllers, "pids")
+	}
+	for _, dir := range []string{cgroupRoot, slice} {
+		for _, c := range controllers {
+			if err := os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte("+"+c), 0644); err != nil {
+				return nil, fmt.Errorf("无法在 %s 启用 cgroup 控制器 %s: %w", dir, c, err)
+			}
+		}
+	}
+
+	// === 创建进程自己的 cgroup 并写入限制 ===
+	dir := cgroupPath(proc)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建 cgroup '%s' 失败: %w", dir, err)
+	}
+
+	settings := make(map[string]string)
+	if proc.Limits.MemoryMax != "" {
+		n, err := parseBytes(proc.Limits.MemoryMax)
+		if err != nil {
+			return nil, fmt.Errorf("limits.memory_max: %w", err)
+		}
+		settings["memory.max"] = "max"
+		if n >= 0 {
+			settings["memory.max"] = strconv.FormatInt(n, 10)
+		}
+	}
+	if proc.Limits.CPUMax != "" {
+		v, err := config.ParseCPUMax(proc.Limits.CPUMax)
+		if err != nil {
+			return nil, fmt.Errorf("limits.cpu_max: %w", err)
+		}
+		settings["cpu.max"] = v
+	}
+	if proc.Limits.PidsMax != "" {
+		v, err := parseCount(proc.Limits.PidsMax)
+		if err != nil {
+			return nil, fmt.Errorf("limits.pids_max: %w", err)
+		}
+		if v == unlimited {
+			v = "max"
+		}
+		settings["pids.max"] = v
+	}
+	for file, value := range settings {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+			return nil, fmt.Errorf("写入 %s=%s 失败: %w", file, value, err)
+		}
+	}
+
+	// === 让子进程在 clone 时直接进入该 cgroup ===
+	fd, err := syscall.Open(dir, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("打开 cgroup '%s' 失败: %w", dir, err)
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = fd
+
+	return func() { syscall.Close(fd) }, nil
+}
+
+// removeCgroup 删除进程的 cgroup 目录。
+// cgroup 中仍有进程时删除会失败，此时保留目录，下次启动时复用。
+func removeCgroup(proc config.Process) {
+	if !hasCgroupLimits(proc.Limits) {
+		return
+	}
+	os.Remove(cgroupPath(proc))
+}
+
+// GetCgroupUsage 读取进程所在 cgroup 的资源使用情况。
+// 进程未配置 cgroup 限制或 cgroup 不存在时返回 nil。
+func GetCgroupUsage(proc config.Process) (*CgroupUsage, error) {
+	if !hasCgroupLimits(proc.Limits) {
+		return nil, nil
+	}
+	dir := cgroupPath(proc)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, nil
+	}
+
+	usage := &CgroupUsage{MemoryMax: -1, PidsMax: -1}
+
+	if v, err := readCgroupInt(dir, "memory.current"); err == nil {
+		usage.MemoryCurrent = v
+	}
+	if v, err := readCgroupInt(dir, "memory.max"); err == nil {
+		usage.MemoryMax = v
+	}
+	if v, err := readCgroupInt(dir, "pids.current"); err == nil {
+		usage.PidsCurrent = v
+	}
+	if v, err := readCgroupInt(dir, "pids.max"); err == nil {
+		usage.PidsMax = v
+	}
+
+	// cpu.stat 为 "key value" 格式，只取累计 CPU 时间
+	if f, err := os.Open(filepath.Join(dir, "cpu.stat")); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 2 && fields[0] == "usage_usec" {
+				if v, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+					usage.CPUUsageUsec = v
+				}
+			}
+		}
+		f.Close()
+	}
+
+	return usage, nil
+}
+
+// readCgroupInt 读取 cgroup 中只包含一个整数的文件，"max" 返回 -1。
+func readCgroupInt(dir, file string) (int64, error) {
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return 0, err
+	}
+	s := strings.TrimSpace(string(data))
+	if s == "max" {
+		return -1, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
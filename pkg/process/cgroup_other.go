@@ -0,0 +1,26 @@
+//go:build !linux
+
+package process
+
+import (
+	"fmt"
+	"os/exec"
+
+	"procmate/pkg/config"
+)
+
+// setupCgroup 在非 Linux 平台上不支持 cgroup，仅打印警告。
+func setupCgroup(cmd *exec.Cmd, proc config.Process) (func(), error) {
+	if hasCgroupLimits(proc.Limits) {
+		fmt.Printf("⚠️ 当前平台不支持 cgroup，忽略进程 '%s' 的 cgroup 限制。\n", proc.Name)
+	}
+	return func() {}, nil
+}
+
+// removeCgroup 在非 Linux 平台上不做任何事。
+func removeCgroup(proc config.Process) {}
+
+// GetCgroupUsage 在非 Linux 平台上始终返回 nil。
+func GetCgroupUsage(proc config.Process) (*CgroupUsage, error) {
+	return nil, nil
+}
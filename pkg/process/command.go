@@ -0,0 +1,172 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"procmate/pkg/config"
+)
+
+// ExecHelperArg 是 procmate 内置启动器的参数名。
+// 需要设置 umask 或 rlimit 时，procmate 会以 `procmate __exec [选项] -- 命令...` 的形式重新执行自身，
+// 启动器设置好这些属性后通过 exec 替换为目标命令，因此不会多出一个中间进程。
+const ExecHelperArg = "__exec"
+
+// commandArgv 根据进程配置确定要执行的命令行。
+// - 配置了 args: 直接执行 args，不经过 shell。
+// - shell 为空或 bash: bash -c <command> (默认行为)。
+// - shell 为 sh: sh -c <command>。
+// - shell 为 none: 按 shell 规则拆分 command 后直接执行 (仅支持引号和反斜杠转义)。
+func commandArgv(proc config.Process) ([]string, error) {
+	if len(proc.Args) > 0 {
+		if proc.Command != "" {
+			return nil, fmt.Errorf("command 与 args 不能同时配置")
+		}
+		if proc.Shell != "" && proc.Shell != "none" {
+			return nil, fmt.Errorf("配置了 args 时 shell 只能为空或 none")
+		}
+		return proc.Args, nil
+	}
+
+	if strings.TrimSpace(proc.Command) == "" {
+		return nil, fmt.Errorf("未配置 command 或 args")
+	}
+
+	switch proc.Shell {
+	case "", "bash":
+		return []string{"bash", "-c", proc.Command}, nil
+	case "sh":
+		return []string{"sh", "-c", proc.Command}, nil
+	case "none":
+		return splitCommand(proc.Command)
+	default:
+		return nil, fmt.Errorf("不支持的 shell '%s'，可选值: bash / sh / none", proc.Shell)
+	}
+}
+
+// launcherArgs 返回需要交给内置启动器处理的参数 (umask 和 rlimit)。
+// 需要经由启动器执行且 cred 不为空时，运行身份也交给启动器切换：
+// 提高 rlimit 的硬限制需要特权，必须在降权之前完成。
+func launcherArgs(proc config.Process, cred *credential) ([]string, error) {
+	var args []string
+	if proc.Umask != "" {
+		mask, err := parseUmask(proc.Umask)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--umask", fmt.Sprintf("%04o", mask))
+	}
+
+	limits, err := rlimitArgs(proc.Limits)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range limits {
+		args = append(args, "--rlimit", l)
+	}
+	if len(args) > 0 && cred != nil {
+		groups := make([]string, len(cred.Groups))
+		for i, g := range cred.Groups {
+			groups[i] = strconv.FormatUint(uint64(g), 10)
+		}
+		args = append(args, "--credential", fmt.Sprintf("%d:%d:%s", cred.Uid, cred.Gid, strings.Join(groups, ",")))
+	}
+	return args, nil
+}
+
+// usesLauncher 判断进程是否经由内置启动器执行。
+func usesLauncher(proc config.Process) bool {
+	args, err := launcherArgs(proc, nil)
+	return err == nil && len(args) > 0
+}
+
+// buildCommand 构造用于启动进程的 exec.Cmd。
+// 需要设置 umask 或 rlimit 时，命令会经由内置启动器执行，运行身份也由启动器切换。
+// 进程运行在独立的进程组中，不会收到终端发给 procmate 的 Ctrl+C。
+func buildCommand(proc config.Process, cred *credential) (*exec.Cmd, error) {
+	argv, err := commandArgv(proc)
+	if err != nil {
+		return nil, err
+	}
+
+	helperArgs, err := launcherArgs(proc, cred)
+	if err != nil {
+		return nil, err
+	}
+	if len(helperArgs) == 0 {
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.SysProcAttr = processGroupSysProcAttr()
+		return cmd, nil
+	}
+
+	if runtime.GOOS == "windows" {
+		return nil, fmt.Errorf("当前平台不支持 umask/limits 配置")
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("获取 procmate 可执行文件路径失败: %w", err)
+	}
+	args := append([]string{ExecHelperArg}, helperArgs...)
+	args = append(args, "--")
+	args = append(args, argv...)
+	cmd := exec.Command(self, args...)
+	cmd.SysProcAttr = processGroupSysProcAttr()
+	return cmd, nil
+}
+
+// splitCommand 按照简化的 shell 规则拆分命令行。
+// 支持空白分隔、单引号、双引号和反斜杠转义，不支持变量展开、管道、重定向等 shell 语法。
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune // 当前所在的引号，0 表示不在引号内
+	escaped := false
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("命令中存在未闭合的引号: %s", command)
+	}
+	if escaped {
+		return nil, fmt.Errorf("命令以未完成的转义符结尾: %s", command)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("命令为空")
+	}
+	return args, nil
+}
@@ -0,0 +1,170 @@
+package process
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"procmate/pkg/config"
+
+	gops "github.com/shirou/gopsutil/v3/process"
+)
+
+// defaultCPUSampleInterval 是计算 CPU 使用率时两次采样的默认间隔
+const defaultCPUSampleInterval = 250 * time.Millisecond
+
+// CPUSampleInterval 非 0 时覆盖配置中的 cpu_sample_ms，由 status --cpu-interval 设置。
+var CPUSampleInterval time.Duration
+
+// cpuSampleInterval 返回计算 CPU 使用率时两次采样的间隔。
+func cpuSampleInterval() time.Duration {
+	if CPUSampleInterval > 0 {
+		return CPUSampleInterval
+	}
+	if ms := config.Current().Settings.CPUSampleMs; ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return defaultCPUSampleInterval
+}
+
+// cpuTime 返回进程累计使用的 CPU 时间 (用户态 + 内核态)。
+func cpuTime(pid int) (time.Duration, bool) {
+	p, err := gops.NewProcess(int32(pid))
+	if err != nil {
+		return 0, false
+	}
+	times, err := p.Times()
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration((times.User + times.System) * float64(time.Second)), true
+}
+
+// sampleCPU 在同一个采样窗口内计算一组进程的当前 CPU 使用率 (100% 表示占满一个核)。
+// 所有进程共用一次等待，总耗时约为一个采样间隔。
+func sampleCPU(pids []int, interval time.Duration) map[int]float64 {
+	before := make(map[int]time.Duration, len(pids))
+	for _, pid := range pids {
+		if t, ok := cpuTime(pid); ok {
+			before[pid] = t
+		}
+	}
+	if len(before) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	time.Sleep(interval)
+	elapsed := time.Since(start)
+
+	result := make(map[int]float64, len(before))
+	for pid, t0 := range before {
+		if t1, ok := cpuTime(pid); ok && t1 >= t0 {
+			result[pid] = float64(t1-t0) / float64(elapsed) * 100
+		}
+	}
+	return result
+}
+
+// cpuSample 是 CPU 跟踪器的一次采样。
+type cpuSample struct {
+	at   time.Time
+	used time.Duration
+}
+
+// cpuHistory 是一个进程最近 5 分钟的 CPU 采样。
+type cpuHistory struct {
+	pid     int
+	samples []cpuSample
+}
+
+var (
+	cpuTrackerMu sync.Mutex
+	cpuTracker   map[string]*cpuHistory // 未启动跟踪器时为 nil
+)
+
+// StartCPUTracker 每隔 interval 对 procs 返回的进程采样一次，用于计算 CPU 使用率的滚动平均值，直到 ctx 结束。
+// 由 watch 守护进程调用。
+func StartCPUTracker(ctx context.Context, interval time.Duration, procs func() []config.Process) {
+	cpuTrackerMu.Lock()
+	cpuTracker = make(map[string]*cpuHistory)
+	cpuTrackerMu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			trackCPU(procs())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// trackCPU 记录一次采样，并丢弃 5 分钟以前的采样和已不存在的进程。
+func trackCPU(procs []config.Process) {
+	now := time.Now()
+	seen := make(map[string]bool, len(procs))
+
+	cpuTrackerMu.Lock()
+	defer cpuTrackerMu.Unlock()
+	for _, proc := range procs {
+		pid, err := ReadPid(proc)
+		if err != nil {
+			continue
+		}
+		used, ok := cpuTime(pid)
+		if !ok {
+			continue
+		}
+		seen[proc.Name] = true
+
+		h := cpuTracker[proc.Name]
+		if h == nil || h.pid != pid {
+			// 进程重启后重新开始计算
+			h = &cpuHistory{pid: pid}
+			cpuTracker[proc.Name] = h
+		}
+		h.samples = append(h.samples, cpuSample{at: now, used: used})
+		for len(h.samples) > 1 && now.Sub(h.samples[1].at) >= 5*time.Minute {
+			h.samples = h.samples[1:]
+		}
+	}
+	for name := range cpuTracker {
+		if !seen[name] {
+			delete(cpuTracker, name)
+		}
+	}
+}
+
+// cpuAverages 返回进程最近 1 分钟和 5 分钟的平均 CPU 使用率。
+// 未启动跟踪器或采样不足时 ok 为 false；采样时间不足一个窗口时按已有的采样计算。
+func cpuAverages(name string, pid int) (avg1m, avg5m float64, ok bool) {
+	cpuTrackerMu.Lock()
+	defer cpuTrackerMu.Unlock()
+	h := cpuTracker[name]
+	if h == nil || h.pid != pid || len(h.samples) < 2 {
+		return 0, 0, false
+	}
+	return h.average(time.Minute), h.average(5 * time.Minute), true
+}
+
+// average 计算最近 window 内的平均 CPU 使用率。
+func (h *cpuHistory) average(window time.Duration) float64 {
+	last := h.samples[len(h.samples)-1]
+	first := h.samples[0]
+	for _, s := range h.samples {
+		if last.at.Sub(s.at) <= window {
+			first = s
+			break
+		}
+	}
+	elapsed := last.at.Sub(first.at)
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(last.used-first.used) / float64(elapsed) * 100
+}
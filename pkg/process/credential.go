@@ -0,0 +1,141 @@
+package process
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+
+	"procmate/pkg/config"
+)
+
+// credential 描述子进程的运行身份。
+type credential struct {
+	Username string
+	HomeDir  string
+	Uid      uint32
+	Gid      uint32
+	Groups   []uint32
+}
+
+// resolveCredential 根据进程配置解析出运行身份。
+// - 未配置 user 和 run_group 时返回 nil，表示沿用 procmate 自身的身份。
+// - user / run_group / supplementary_groups 既可以是名称，也可以是数字 ID。
+// - 未配置 run_group 时使用用户的主组；未配置 supplementary_groups 时使用用户所属的全部组 (同 initgroups)。
+func resolveCredential(proc config.Process) (*credential, error) {
+	if proc.User == "" && proc.RunGroup == "" {
+		return nil, nil
+	}
+
+	cred := &credential{}
+
+	// === 解析用户 ===
+	var u *user.User
+	if proc.User != "" {
+		var err error
+		u, err = lookupUser(proc.User)
+		if err != nil {
+			return nil, err
+		}
+		uid, err := parseID(u.Uid)
+		if err != nil {
+			return nil, fmt.Errorf("用户 '%s' 的 UID 无效: %w", proc.User, err)
+		}
+		gid, err := parseID(u.Gid)
+		if err != nil {
+			return nil, fmt.Errorf("用户 '%s' 的 GID 无效: %w", proc.User, err)
+		}
+		cred.Username = u.Username
+		cred.HomeDir = u.HomeDir
+		cred.Uid = uid
+		cred.Gid = gid
+	} else {
+		// 仅配置了 run_group 时，保持当前用户身份
+		current, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("获取当前用户失败: %w", err)
+		}
+		uid, err := parseID(current.Uid)
+		if err != nil {
+			return nil, fmt.Errorf("当前用户的 UID 无效: %w", err)
+		}
+		cred.Uid = uid
+	}
+
+	// === 解析主组 ===
+	if proc.RunGroup != "" {
+		gid, err := lookupGroupID(proc.RunGroup)
+		if err != nil {
+			return nil, err
+		}
+		cred.Gid = gid
+	}
+
+	// === 解析附加组 ===
+	if len(proc.SupplementaryGroups) > 0 {
+		for _, g := range proc.SupplementaryGroups {
+			gid, err := lookupGroupID(g)
+			if err != nil {
+				return nil, err
+			}
+			cred.Groups = append(cred.Groups, gid)
+		}
+	} else if u != nil {
+		groupIDs, err := u.GroupIds()
+		if err != nil {
+			return nil, fmt.Errorf("获取用户 '%s' 的附加组失败: %w", proc.User, err)
+		}
+		for _, g := range groupIDs {
+			gid, err := parseID(g)
+			if err != nil {
+				continue
+			}
+			cred.Groups = append(cred.Groups, gid)
+		}
+	}
+
+	return cred, nil
+}
+
+// lookupUser 按名称或数字 UID 查找用户。
+func lookupUser(name string) (*user.User, error) {
+	if _, err := parseID(name); err == nil {
+		if u, err := user.LookupId(name); err == nil {
+			return u, nil
+		}
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("查找用户 '%s' 失败: %w", name, err)
+	}
+	return u, nil
+}
+
+// lookupGroupID 按名称或数字 GID 查找用户组，返回 GID。
+func lookupGroupID(name string) (uint32, error) {
+	if gid, err := parseID(name); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, fmt.Errorf("查找用户组 '%s' 失败: %w", name, err)
+	}
+	return parseID(g.Gid)
+}
+
+// parseID 将字符串形式的 UID/GID 转换为数字。
+func parseID(s string) (uint32, error) {
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(id), nil
+}
+
+// parseUmask 解析八进制形式的 umask，例如 "027" 或 "0027"。
+func parseUmask(s string) (uint32, error) {
+	mask, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mask > 0777 {
+		return 0, fmt.Errorf("umask 无效 '%s'，应为八进制数，例如 027", s)
+	}
+	return uint32(mask), nil
+}
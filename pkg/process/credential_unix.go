@@ -0,0 +1,133 @@
+//go:build !windows
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"procmate/pkg/config"
+)
+
+// applyCredential 让子进程以指定身份运行，并允许其读取自己的日志。
+// PID 文件由 procmate 自身写入和信任，因此保持 procmate 的身份不变，
+// 避免被托管进程篡改后诱导 procmate 向任意进程发送信号。
+// 日志同理：目录和文件始终归 procmate 所有，目标用户只通过属组获得读权限，
+// 否则它可以把日志文件替换为指向任意文件的符号链接，让 procmate 以 root 身份写入。
+func applyCredential(cmd *exec.Cmd, cred *credential, proc config.Process) error {
+	if cred == nil {
+		return nil
+	}
+
+	// 经由内置启动器执行时，由启动器在设置 rlimit 之后再切换身份
+	if !usesLauncher(proc) {
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		cmd.SysProcAttr.Credential = &syscall.Credential{
+			Uid:    cred.Uid,
+			Gid:    cred.Gid,
+			Groups: cred.Groups,
+		}
+	}
+
+	logFiles, err := GetManagedLogFiles(proc)
+	if err != nil {
+		return err
+	}
+	defaultLogDir, err := getLogDir(proc)
+	if err != nil {
+		return err
+	}
+	// 早期版本会把默认日志目录交给目标用户，这里收回
+	if info, err := os.Lstat(defaultLogDir); err == nil {
+		if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Geteuid() {
+			if err := os.Lchown(defaultLogDir, os.Geteuid(), os.Getegid()); err != nil {
+				return fmt.Errorf("收回日志目录 '%s' 的属主失败: %w", defaultLogDir, err)
+			}
+		}
+	}
+	for _, logFilePath := range logFiles {
+		if err := checkLogDirNotWritable(logFilePath, cred); err != nil {
+			return err
+		}
+		f, err := openLogFile(logFilePath)
+		if err != nil {
+			return fmt.Errorf("创建日志文件 '%s' 失败: %w", logFilePath, err)
+		}
+		// 通过已打开的文件描述符修改，不会跟随符号链接
+		err = f.Chown(os.Geteuid(), int(cred.Gid))
+		if err == nil {
+			err = f.Chmod(0640)
+		}
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("修改日志文件 '%s' 的属组失败: %w", logFilePath, err)
+		}
+	}
+
+	return nil
+}
+
+// checkLogDirNotWritable 确认目标用户无法替换日志路径上的任何一级目录项。
+// 日志由 procmate 写入，轮转时会按路径重新打开文件 (不带 O_NOFOLLOW)，
+// 目标用户能够写入所在目录时，就能在两次打开之间放入符号链接，让 procmate 以自己的身份写入任意文件。
+// 上级目录可写但设置了粘滞位 (如 /tmp) 时，只要下一级目录归 procmate 或 root 所有，目标用户同样无法替换它。
+func checkLogDirNotWritable(logFilePath string, cred *credential) error {
+	if int(cred.Uid) == os.Geteuid() {
+		return nil
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(logFilePath))
+	if err != nil {
+		return fmt.Errorf("解析日志目录 '%s' 失败: %w", filepath.Dir(logFilePath), err)
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	var child *syscall.Stat_t // 上一次检查的下一级目录
+	for {
+		var st syscall.Stat_t
+		if err := syscall.Stat(dir, &st); err != nil {
+			return fmt.Errorf("读取目录 '%s' 失败: %w", dir, err)
+		}
+		protected := child != nil && st.Mode&syscall.S_ISVTX != 0 &&
+			(child.Uid == 0 || int(child.Uid) == os.Geteuid())
+		if writableBy(&st, cred) && !protected {
+			return fmt.Errorf("日志路径 '%s' 上的目录 '%s' 可被运行身份 (uid %d) 写入，procmate 写入或轮转日志时可能被符号链接劫持，请使用该用户不可写的目录",
+				logFilePath, dir, cred.Uid)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		child = &st
+		dir = parent
+	}
+}
+
+// writableBy 判断目录是否可被 cred 描述的用户写入。
+func writableBy(st *syscall.Stat_t, cred *credential) bool {
+	if st.Mode&0002 != 0 {
+		return true
+	}
+	if st.Uid == cred.Uid {
+		return st.Mode&0200 != 0
+	}
+	if st.Mode&0020 == 0 {
+		return false
+	}
+	if st.Gid == cred.Gid {
+		return true
+	}
+	for _, g := range cred.Groups {
+		if st.Gid == g {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package process
+
+import (
+	"fmt"
+	"os/exec"
+
+	"procmate/pkg/config"
+)
+
+// applyCredential 在 Windows 上不支持切换运行身份。
+func applyCredential(cmd *exec.Cmd, cred *credential, proc config.Process) error {
+	if cred == nil {
+		return nil
+	}
+	return fmt.Errorf("当前平台不支持 user/run_group 配置")
+}
@@ -0,0 +1,39 @@
+//go:build !windows
+
+package process
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// detachedSysProcAttr 让进程在新的会话中运行，不受启动它的终端影响。
+func detachedSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+// processGroupSysProcAttr 让托管进程运行在独立的进程组中，
+// 终端上的 Ctrl+C 只发送给 procmate 本身，由 procmate 按依赖顺序停止各进程。
+func processGroupSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcessGroup 向以 processGroupSysProcAttr 启动的进程所在的整个进程组发送信号，
+// shell 派生的子进程也会收到。进程不是进程组组长 (如由旧版本启动) 时只向进程本身发送。
+// sig 为 0 时只检查进程组中是否仍有进程存活。
+func signalProcessGroup(p *os.Process, sig syscall.Signal) error {
+	err := syscall.Kill(-p.Pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		return p.Signal(sig)
+	}
+	return err
+}
+
+// killProcessGroup 强制结束以 processGroupSysProcAttr 启动的进程及其整个进程组，
+// 用于启动过程中途失败时清理，避免 shell 派生的子进程继续运行。
+func killProcessGroup(p *os.Process) {
+	if err := syscall.Kill(-p.Pid, syscall.SIGKILL); err != nil {
+		p.Kill()
+	}
+}
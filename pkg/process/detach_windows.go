@@ -0,0 +1,28 @@
+//go:build windows
+
+package process
+
+import (
+	"os"
+	"syscall"
+)
+
+// detachedSysProcAttr 在 Windows 上使用默认属性。
+func detachedSysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+// processGroupSysProcAttr 在 Windows 上使用默认属性。
+func processGroupSysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+// signalProcessGroup 在 Windows 上只向进程本身发送信号。
+func signalProcessGroup(p *os.Process, sig syscall.Signal) error {
+	return p.Signal(sig)
+}
+
+// killProcessGroup 在 Windows 上只结束进程本身。
+func killProcessGroup(p *os.Process) {
+	p.Kill()
+}
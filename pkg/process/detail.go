@@ -0,0 +1,92 @@
+package process
+
+import (
+	"fmt"
+	"sort"
+
+	"procmate/pkg/config"
+
+	gops "github.com/shirou/gopsutil/v3/process"
+)
+
+// ProcessDetail 是 `status <name> --detail` 展示的进程详细信息。
+// 无权读取的字段 (如其他用户进程的环境变量) 保持零值。
+type ProcessDetail struct {
+	ProcessInfo
+	Cmdline    string         // 完整命令行
+	Cwd        string         // 工作目录
+	Env        []string       // 环境变量，已排序
+	Threads    int32          // 线程数
+	OpenFDs    int32          // 打开的文件描述符数量
+	ReadBytes  uint64         // 累计读取字节数
+	WriteBytes uint64         // 累计写入字节数
+	Children   []ChildProcess // 子进程树
+	History    ProcessHistory // 重启次数和最近的退出记录
+}
+
+// ChildProcess 是子进程树中的一个节点。
+type ChildProcess struct {
+	PID      int32
+	Cmdline  string
+	Children []ChildProcess
+}
+
+// GetProcessDetail 获取进程的详细信息。进程未运行时只包含基本状态和历史。
+func GetProcessDetail(proc config.Process) (*ProcessDetail, error) {
+	info, err := GetProcessInfo(proc)
+	if err != nil {
+		return nil, err
+	}
+	detail := &ProcessDetail{ProcessInfo: *info}
+
+	history, err := GetProcessHistory(proc.Name)
+	if err != nil {
+		return nil, fmt.Errorf("读取进程 '%s' 的历史失败: %w", proc.Name, err)
+	}
+	detail.History = history
+
+	if !info.IsRunning || info.TimedOut {
+		return detail, nil
+	}
+	p, err := gops.NewProcess(int32(info.PID))
+	if err != nil {
+		// 进程刚刚退出
+		return detail, nil
+	}
+
+	detail.Cmdline, _ = p.Cmdline()
+	detail.Cwd, _ = p.Cwd()
+	if env, err := p.Environ(); err == nil {
+		for _, kv := range env {
+			if kv != "" {
+				detail.Env = append(detail.Env, kv)
+			}
+		}
+		sort.Strings(detail.Env)
+	}
+	detail.Threads, _ = p.NumThreads()
+	detail.OpenFDs, _ = p.NumFDs()
+	if io, err := p.IOCounters(); err == nil {
+		detail.ReadBytes = io.ReadBytes
+		detail.WriteBytes = io.WriteBytes
+	}
+
+	if children, err := childProcesses(); err == nil {
+		detail.Children = childTree(children, int32(info.PID))
+	}
+	return detail, nil
+}
+
+// childTree 构造 pid 的子进程树。
+func childTree(children map[int32][]int32, pid int32) []ChildProcess {
+	var nodes []ChildProcess
+	for _, child := range children[pid] {
+		node := ChildProcess{PID: child}
+		if p, err := gops.NewProcess(child); err == nil {
+			node.Cmdline, _ = p.Cmdline()
+		}
+		node.Children = childTree(children, child)
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
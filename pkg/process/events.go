@@ -0,0 +1,194 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hpcloud/tail"
+)
+
+// 事件类型
+const (
+	EventSpawn       = "spawn"        // 进程已启动
+	EventReady       = "ready"        // 进程已就绪
+	EventUnready     = "unready"      // 运行中的进程变为未就绪
+	EventProbeFailed = "probe_failed" // 就绪检查在超时时间内未通过
+	EventExit        = "exit"         // 进程退出 (由启动它的 procmate 观察到)
+	EventOffline     = "offline"      // watch 发现进程已不在运行
+	EventRestart     = "restart"      // watch 自动重启进程
+	EventStop        = "stop"         // 请求停止进程
+	EventKill        = "kill"         // 进程未在超时时间内退出，升级为 SIGKILL
+	EventSkip        = "skip"         // 定时任务的上次运行尚未结束，跳过本次运行
+)
+
+// 事件日志的轮转参数
+const (
+	maxEventLogSize    = 10 * 1024 * 1024
+	maxEventLogBackups = 5
+)
+
+// Event 是事件日志 <runtime_dir>/events.log 中的一行。
+type Event struct {
+	Time    time.Time `json:"time"`
+	Process string    `json:"process"`
+	Type    string    `json:"type"`
+	PID     int       `json:"pid,omitempty"`
+	Code    *int      `json:"code,omitempty"`   // 退出码，仅用于 exit，被信号终止时为 -1
+	Signal  string    `json:"signal,omitempty"` // 终止进程的信号，仅用于 exit
+	Message string    `json:"message,omitempty"`
+}
+
+// EventFilter 用于筛选事件。
+type EventFilter struct {
+	Since     time.Time       // 只返回该时间之后的事件，零值表示不限制
+	Processes map[string]bool // 只返回这些进程的事件，为空表示不限制
+}
+
+// Match 判断事件是否满足筛选条件。
+func (f EventFilter) Match(e Event) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	return len(f.Processes) == 0 || f.Processes[e.Process]
+}
+
+// eventsMu 保证本进程内的事件逐行写入。
+// 多个 procmate 进程以 O_APPEND 方式写入同一个文件，每行一次 write，不会互相穿插。
+var eventsMu sync.Mutex
+
+// getEventLogFile 返回事件日志的路径。
+func getEventLogFile() (string, error) {
+	runtimeDir, err := ensureCommonRuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(runtimeDir, "events.log"), nil
+}
+
+// RecordEvent 将一个事件追加到事件日志中，Time 为空时使用当前时间。
+// 写入失败不影响进程管理，只打印提示。
+func RecordEvent(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if err := writeEvent(e); err != nil {
+		fmt.Printf("⚠️ 写入事件日志失败: %v\n", err)
+	}
+}
+
+func writeEvent(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	path, err := getEventLogFile()
+	if err != nil {
+		return err
+	}
+
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	if fi, err := os.Stat(path); err == nil && fi.Size() >= maxEventLogSize {
+		rotateEventLog(path)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// rotateEventLog 按 lumberjack 的命名方式 (events-<UTC 时间>.log) 轮转事件日志，并删除多余的备份。
+// 多个 procmate 同时轮转时，后执行的重命名会失败或轮转一个很小的文件，不影响事件的读取。
+func rotateEventLog(path string) {
+	backup := strings.TrimSuffix(path, ".log") + "-" + time.Now().UTC().Format(backupTimeFormat) + ".log"
+	if err := os.Rename(path, backup); err != nil {
+		return
+	}
+	backups := listLogBackups(path, time.UTC)
+	for len(backups) > maxEventLogBackups {
+		os.Remove(backups[0].Path)
+		backups = backups[1:]
+	}
+}
+
+// ReadEvents 按时间顺序读取满足条件的历史事件 (包括已轮转的备份)，返回当前事件日志已读取到的位置。
+func ReadEvents(filter EventFilter, fn func(Event)) (int64, error) {
+	path, err := getEventLogFile()
+	if err != nil {
+		return 0, err
+	}
+	handle := func(line string) {
+		var e Event
+		if json.Unmarshal([]byte(line), &e) == nil && filter.Match(e) {
+			fn(e)
+		}
+	}
+
+	for _, b := range listLogBackups(path, time.UTC) {
+		// 备份的轮转时间早于 since 时，其中的事件都不需要
+		if !filter.Since.IsZero() && b.RotatedAt.Before(filter.Since) {
+			continue
+		}
+		if _, err := scanLogFile(b.Path, -1, handle); err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+	}
+
+	// 只读取当前已写完的内容，之后从这里继续追踪
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return scanLogFile(path, fi.Size(), handle)
+}
+
+// FollowEvents 从 offset 开始持续读取新写入的事件，直到 ctx 结束。事件日志轮转后自动重新打开。
+func FollowEvents(ctx context.Context, offset int64, filter EventFilter, fn func(Event)) error {
+	path, err := getEventLogFile()
+	if err != nil {
+		return err
+	}
+	t, err := tail.TailFile(path, tail.Config{
+		ReOpen:    true,
+		Follow:    true,
+		MustExist: false,
+		Location:  &tail.SeekInfo{Offset: offset, Whence: io.SeekStart},
+		Logger:    tail.DiscardingLogger,
+	})
+	if err != nil {
+		return err
+	}
+	defer t.Cleanup()
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-t.Lines:
+			if !ok {
+				return t.Err()
+			}
+			if line.Err != nil {
+				continue
+			}
+			var e Event
+			if json.Unmarshal([]byte(line.Text), &e) == nil && filter.Match(e) {
+				fn(e)
+			}
+		}
+	}
+}
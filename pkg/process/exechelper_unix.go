@@ -0,0 +1,150 @@
+//go:build !windows
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// rlimitResources 将配置中的限制名称映射为 rlimit 资源编号。
+var rlimitResources = map[string]int{
+	"nofile":  unix.RLIMIT_NOFILE,
+	"nproc":   unix.RLIMIT_NPROC,
+	"core":    unix.RLIMIT_CORE,
+	"memlock": unix.RLIMIT_MEMLOCK,
+	"as":      unix.RLIMIT_AS,
+}
+
+// RunExecHelper 是内置启动器的入口，由 main 在解析命令行之前调用。
+// 参数格式: [--umask 0027] [--rlimit name=value]... [--credential uid:gid:g1,g2] -- 命令 参数...
+// 先以 procmate 的身份设置 umask 和 rlimit，再切换运行身份，最后通过 exec 替换为目标命令；
+// 任何失败都会以 127 退出，并把原因写入标准错误 (即进程日志)。
+func RunExecHelper(args []string) {
+	fail := func(format string, a ...interface{}) {
+		fmt.Fprintf(os.Stderr, "procmate: "+format+"\n", a...)
+		os.Exit(127)
+	}
+
+	var argv []string
+	var cred string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--umask":
+			if i+1 >= len(args) {
+				fail("--umask 缺少参数")
+			}
+			i++
+			mask, err := parseUmask(args[i])
+			if err != nil {
+				fail("%v", err)
+			}
+			syscall.Umask(int(mask))
+		case "--rlimit":
+			if i+1 >= len(args) {
+				fail("--rlimit 缺少参数")
+			}
+			i++
+			if err := applyRlimit(args[i]); err != nil {
+				fail("%v", err)
+			}
+		case "--credential":
+			if i+1 >= len(args) {
+				fail("--credential 缺少参数")
+			}
+			i++
+			cred = args[i]
+		case "--":
+			argv = args[i+1:]
+			i = len(args)
+		default:
+			fail("未知的启动器参数 '%s'", args[i])
+		}
+	}
+
+	if cred != "" {
+		if err := switchCredential(cred); err != nil {
+			fail("%v", err)
+		}
+	}
+
+	if len(argv) == 0 {
+		fail("未指定要执行的命令")
+	}
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		fail("查找命令 '%s' 失败: %v", argv[0], err)
+	}
+	err = syscall.Exec(path, argv, os.Environ())
+	fail("执行命令 '%s' 失败: %v", path, err)
+}
+
+// applyRlimit 解析 name=value 并同时设置软限制和硬限制 (同 ulimit)。
+func applyRlimit(spec string) error {
+	name, value, ok := strings.Cut(spec, "=")
+	if !ok {
+		return fmt.Errorf("rlimit 参数格式错误 '%s'", spec)
+	}
+	resource, ok := rlimitResources[name]
+	if !ok {
+		return fmt.Errorf("不支持的 rlimit '%s'", name)
+	}
+
+	limit := uint64(unix.RLIM_INFINITY)
+	if value != unlimited {
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("rlimit %s 的取值无效 '%s'", name, value)
+		}
+		limit = n
+	}
+
+	// 使用 syscall.Setrlimit，以便 Go 运行时在 exec 时不再恢复其启动时调整过的 nofile 限制
+	if err := syscall.Setrlimit(resource, &syscall.Rlimit{Cur: limit, Max: limit}); err != nil {
+		return fmt.Errorf("设置 rlimit %s=%s 失败: %w", name, value, err)
+	}
+	return nil
+}
+
+// switchCredential 解析 uid:gid:g1,g2 并依次设置附加组、主组和用户 (同 SysProcAttr.Credential)。
+func switchCredential(spec string) error {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 {
+		return fmt.Errorf("运行身份参数格式错误 '%s'", spec)
+	}
+	uid, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return fmt.Errorf("运行身份参数格式错误 '%s'", spec)
+	}
+	gid, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return fmt.Errorf("运行身份参数格式错误 '%s'", spec)
+	}
+	groups := []int{}
+	if parts[2] != "" {
+		for _, g := range strings.Split(parts[2], ",") {
+			n, err := strconv.Atoi(g)
+			if err != nil {
+				return fmt.Errorf("运行身份参数格式错误 '%s'", spec)
+			}
+			groups = append(groups, n)
+		}
+	}
+
+	if err := syscall.Setgroups(groups); err != nil {
+		return fmt.Errorf("设置附加组失败: %w", err)
+	}
+	if err := syscall.Setgid(gid); err != nil {
+		return fmt.Errorf("设置 GID %d 失败: %w", gid, err)
+	}
+	if err := syscall.Setuid(uid); err != nil {
+		return fmt.Errorf("设置 UID %d 失败: %w", uid, err)
+	}
+	return nil
+}
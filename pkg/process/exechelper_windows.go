@@ -0,0 +1,14 @@
+//go:build windows
+
+package process
+
+import (
+	"fmt"
+	"os"
+)
+
+// RunExecHelper 在 Windows 上不可用，umask 和 rlimit 均不受支持。
+func RunExecHelper(args []string) {
+	fmt.Fprintln(os.Stderr, "procmate: 当前平台不支持内置启动器")
+	os.Exit(127)
+}
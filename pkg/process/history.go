@@ -0,0 +1,101 @@
+package process
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// maxExitRecords 是每个进程保留的退出记录数量
+const maxExitRecords = 20
+
+// ExitRecord 是一次进程退出的记录。
+type ExitRecord struct {
+	Time   time.Time `json:"time"`
+	Code   int       `json:"code"`             // 退出码，被信号终止时为 -1
+	Signal string    `json:"signal,omitempty"` // 终止进程的信号，正常退出时为空
+}
+
+// ProcessHistory 是持久化在 <runtime_dir>/history/<name>.json 中的进程历史。
+// 只有由常驻的 procmate (watch / run) 启动的进程才能观察到退出，其它命令只读取。
+type ProcessHistory struct {
+	Restarts int          `json:"restarts"` // 被 watch 自动重启的累计次数
+	Exits    []ExitRecord `json:"exits"`    // 最近的退出记录，新的在前
+
+	// 以下字段仅用于定时任务
+	LastRun     *JobRun `json:"last_run,omitempty"`     // 最近一次运行
+	SkippedRuns int     `json:"skipped_runs,omitempty"` // 因上次运行尚未结束而跳过的累计次数
+}
+
+// JobRun 是定时任务的一次运行。
+type JobRun struct {
+	Started time.Time   `json:"started"`
+	PID     int         `json:"pid"`
+	Exit    *ExitRecord `json:"exit,omitempty"` // 退出状态，运行中或未被观察到时为 nil
+}
+
+// getHistoryFile 返回进程历史文件的路径。
+func getHistoryFile(name string) (string, error) {
+	runtimeDir, err := ensureCommonRuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	historyDir := filepath.Join(runtimeDir, "history")
+	if err := os.MkdirAll(historyDir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(historyDir, name+".json"), nil
+}
+
+// GetProcessHistory 读取进程历史，文件不存在时返回空历史。
+func GetProcessHistory(name string) (ProcessHistory, error) {
+	var h ProcessHistory
+	path, err := getHistoryFile(name)
+	if err != nil {
+		return h, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	err = json.Unmarshal(data, &h)
+	return h, err
+}
+
+// updateHistory 修改并保存进程历史，调用方需持有 statsMu。
+func updateHistory(name string, fn func(*ProcessHistory)) error {
+	h, _ := GetProcessHistory(name)
+	fn(&h)
+	if len(h.Exits) > maxExitRecords {
+		h.Exits = h.Exits[:maxExitRecords]
+	}
+
+	path, err := getHistoryFile(name)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	// 先写临时文件再重命名，读取方不会看到写了一半的内容
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// exitRecordOf 根据子进程的退出状态生成退出记录。
+func exitRecordOf(state *os.ProcessState) ExitRecord {
+	record := ExitRecord{Time: time.Now(), Code: state.ExitCode()}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		record.Signal = ws.Signal().String()
+	}
+	return record
+}
@@ -0,0 +1,122 @@
+package process
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"procmate/pkg/config"
+)
+
+// unlimited 是资源限制中表示"不限制"的取值。
+const unlimited = "unlimited"
+
+// CgroupUsage 表示进程所在 cgroup 的资源使用情况。
+// 上限字段为 -1 表示不限制。
+type CgroupUsage struct {
+	MemoryCurrent int64 `json:"memory_current"` // 当前内存使用 (字节)
+	MemoryMax     int64 `json:"memory_max"`     // 内存上限 (字节)
+	PidsCurrent   int64 `json:"pids_current"`   // 当前任务数
+	PidsMax       int64 `json:"pids_max"`       // 任务数上限
+	CPUUsageUsec  int64 `json:"cpu_usage_usec"` // 累计 CPU 时间 (微秒)
+}
+
+// parseCount 解析数量类的限制值 (例如 nofile、nproc)。
+func parseCount(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == unlimited || s == "max" {
+		return unlimited, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("无效的数量 '%s'", s)
+	}
+	return strconv.FormatUint(n, 10), nil
+}
+
+// parseBytes 解析容量类的限制值，支持 K/M/G/T 后缀 (1024 进制)。
+// 返回 -1 表示不限制。
+func parseBytes(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == unlimited || s == "max" {
+		return -1, nil
+	}
+
+	multiplier := int64(1)
+	upper := strings.ToUpper(strings.TrimSuffix(strings.TrimSuffix(s, "B"), "b"))
+	if upper != "" {
+		switch upper[len(upper)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			upper = upper[:len(upper)-1]
+		}
+	}
+
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无效的容量 '%s'", s)
+	}
+	return n * multiplier, nil
+}
+
+// rlimitArgs 根据 limits 配置生成传给启动器的 rlimit 参数，格式为 name=value。
+// value 为数量或字节数，"unlimited" 表示不限制。
+func rlimitArgs(limits config.Limits) ([]string, error) {
+	var args []string
+
+	counts := []struct {
+		name  string
+		value string
+	}{
+		{"nofile", limits.Nofile},
+		{"nproc", limits.Nproc},
+	}
+	for _, c := range counts {
+		if c.value == "" {
+			continue
+		}
+		v, err := parseCount(c.value)
+		if err != nil {
+			return nil, fmt.Errorf("limits.%s: %w", c.name, err)
+		}
+		args = append(args, c.name+"="+v)
+	}
+
+	sizes := []struct {
+		name  string
+		value string
+	}{
+		{"core", limits.Core},
+		{"memlock", limits.Memlock},
+		{"as", limits.AS},
+	}
+	for _, c := range sizes {
+		if c.value == "" {
+			continue
+		}
+		n, err := parseBytes(c.value)
+		if err != nil {
+			return nil, fmt.Errorf("limits.%s: %w", c.name, err)
+		}
+		v := unlimited
+		if n >= 0 {
+			v = strconv.FormatInt(n, 10)
+		}
+		args = append(args, c.name+"="+v)
+	}
+
+	return args, nil
+}
+
+// hasCgroupLimits 判断进程是否配置了 cgroup 限制。
+func hasCgroupLimits(limits config.Limits) bool {
+	return limits.MemoryMax != "" || limits.CPUMax != "" || limits.PidsMax != ""
+}
@@ -0,0 +1,132 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"procmate/pkg/config"
+)
+
+// LockWait 为 true 时，锁被其他 procmate 占用时等待其释放，否则立即失败。
+var LockWait bool
+
+// errWouldBlock 表示锁已被其他进程持有。
+var errWouldBlock = errors.New("lock is held by another process")
+
+// LockedError 表示锁被其他 procmate 持有且未等待。
+type LockedError struct {
+	What   string // 锁的描述
+	Holder string // 持有者，如 "PID 123 (procmate start all)"
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s被 %s 占用，请稍后重试或使用 --wait 等待其释放", e.What, e.Holder)
+}
+
+// processMutexes 保存每个进程在本进程内的互斥锁。
+// flock 对同一进程内重复打开的文件同样互斥，本进程内的竞争 (如启动超时后立即停止) 总是等待。
+var processMutexes sync.Map
+
+// FileLock 是 runtime_dir 中的一个建议锁 (advisory lock)，用于避免多个 procmate 同时操作同一进程。
+//   - 全局锁 <runtime_dir>/procmate.lock：操作部分进程时以共享方式持有，
+//     调整整个进程集合 (如 scale) 时以独占方式持有。
+//   - 进程锁 <runtime_dir>/locks/<name>.lock：启动、停止某个进程期间以独占方式持有。
+//
+// 只读命令 (status、log 等) 不获取锁。
+type FileLock struct {
+	file      *os.File
+	local     *sync.Mutex
+	exclusive bool
+}
+
+// LockGlobal 获取全局锁。
+func LockGlobal(exclusive bool) (*FileLock, error) {
+	runtimeDir, err := ensureCommonRuntimeDir()
+	if err != nil {
+		return nil, err
+	}
+	return acquireLock(filepath.Join(runtimeDir, "procmate.lock"), "全局锁", exclusive)
+}
+
+// lockProcess 获取进程锁。
+func lockProcess(proc config.Process) (*FileLock, error) {
+	mu, _ := processMutexes.LoadOrStore(proc.Name, &sync.Mutex{})
+	local := mu.(*sync.Mutex)
+	local.Lock()
+
+	path, err := getLockFile(proc)
+	if err != nil {
+		local.Unlock()
+		return nil, err
+	}
+	lock, err := acquireLock(path, fmt.Sprintf("进程 '%s' 的锁", proc.Name), true)
+	if err != nil {
+		local.Unlock()
+		return nil, err
+	}
+	lock.local = local
+	return lock, nil
+}
+
+// acquireLock 获取 path 上的锁。独占持有时在其中记录持有者，便于冲突时提示；
+// 共享持有者可能有多个，互相覆盖会记录错误的进程，因此不记录。
+func acquireLock(path, what string, exclusive bool) (*FileLock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开锁文件 %s 失败: %w", path, err)
+	}
+
+	err = tryLockFile(f, exclusive)
+	if errors.Is(err, errWouldBlock) {
+		holder := describeLockHolder(f)
+		if !LockWait {
+			f.Close()
+			return nil, &LockedError{What: what, Holder: holder}
+		}
+		fmt.Printf("⏳ %s被 %s 占用，等待其释放...\n", what, holder)
+		err = lockFile(f, exclusive)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("获取%s失败: %w", what, err)
+	}
+
+	// 共享持有时不存在独占持有者，文件中的记录只可能是异常退出的独占持有者留下的，一并清除
+	f.Truncate(0)
+	if exclusive {
+		f.WriteAt([]byte(fmt.Sprintf("%d\n%s\n", os.Getpid(), strings.Join(os.Args, " "))), 0)
+	}
+	return &FileLock{file: f, exclusive: exclusive}, nil
+}
+
+// describeLockHolder 读取锁文件中记录的持有者，没有记录时说明锁被共享持有。
+func describeLockHolder(f *os.File) string {
+	buf := make([]byte, 4096)
+	n, _ := f.ReadAt(buf, 0)
+	pidLine, command, _ := strings.Cut(string(buf[:n]), "\n")
+	pid, err := strconv.Atoi(strings.TrimSpace(pidLine))
+	if err != nil {
+		return "其他 procmate 进程"
+	}
+	return fmt.Sprintf("PID %d (%s)", pid, strings.TrimSpace(command))
+}
+
+// Unlock 释放锁。
+func (l *FileLock) Unlock() {
+	if l == nil {
+		return
+	}
+	if l.exclusive {
+		l.file.Truncate(0)
+	}
+	unlockFile(l.file)
+	l.file.Close()
+	if l.local != nil {
+		l.local.Unlock()
+	}
+}
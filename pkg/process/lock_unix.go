@@ -0,0 +1,40 @@
+//go:build !windows
+
+package process
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLockFile 尝试以非阻塞方式对文件加 flock，已被占用时返回 errWouldBlock。
+func tryLockFile(f *os.File, exclusive bool) error {
+	err := syscall.Flock(int(f.Fd()), flockMode(exclusive)|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errWouldBlock
+	}
+	return err
+}
+
+// lockFile 对文件加 flock，阻塞直到获取成功。
+func lockFile(f *os.File, exclusive bool) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), flockMode(exclusive))
+		if !errors.Is(err, syscall.EINTR) {
+			return err
+		}
+	}
+}
+
+// unlockFile 释放文件上的 flock。
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+func flockMode(exclusive bool) int {
+	if exclusive {
+		return syscall.LOCK_EX
+	}
+	return syscall.LOCK_SH
+}
@@ -0,0 +1,46 @@
+//go:build windows
+
+package process
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockOffsetHigh 是被锁定字节位置的高 32 位。Windows 上的文件锁是强制锁，
+// 锁定文件内容以外的区域，其他进程仍能读取锁文件中记录的持有者。
+const lockOffsetHigh = 1
+
+// tryLockFile 尝试以非阻塞方式锁定文件，已被占用时返回 errWouldBlock。
+func tryLockFile(f *os.File, exclusive bool) error {
+	err := lockFileEx(f, lockFlags(exclusive)|windows.LOCKFILE_FAIL_IMMEDIATELY)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errWouldBlock
+	}
+	return err
+}
+
+// lockFile 锁定文件，阻塞直到获取成功。
+func lockFile(f *os.File, exclusive bool) error {
+	return lockFileEx(f, lockFlags(exclusive))
+}
+
+// unlockFile 释放文件锁。
+func unlockFile(f *os.File) error {
+	ol := &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+}
+
+func lockFileEx(f *os.File, flags uint32) error {
+	ol := &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+	return windows.LockFileEx(windows.Handle(f.Fd()), flags, 0, 1, 0, ol)
+}
+
+func lockFlags(exclusive bool) uint32 {
+	if exclusive {
+		return windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	return 0
+}
@@ -2,52 +2,102 @@ package process
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"procmate/pkg/config"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/hpcloud/tail"
 )
 
 // TailLog 查找、追踪并美化打印进程的日志
-func TailLog(proc config.Process) error {
+// - 未指定 -n / --since 时，从当前日志文件开头输出。
+// - 指定了历史范围时，按需读取 lumberjack 轮转出的备份 (包括 .gz)，然后从当前文件末尾继续追踪。
+func TailLog(proc config.Process, opts TailOptions) error {
 	var logFiles []string
 	
-	// 获取procmate管理的日志文件路径
-	logFilePath, err := GetLogFile(proc)
+	// 获取procmate管理的日志文件路径 (stdout 以及单独记录时的 stderr)
+	managedLogFiles, err := GetManagedLogFiles(proc)
 	if err != nil {
 		return fmt.Errorf("无法获取 '%s' 的日志文件路径: %w", proc.Name, err)
 	}
-	logFiles = append(logFiles, logFilePath)
+	logFiles = append(logFiles, managedLogFiles...)
 	
 	// 添加进程配置中指定的额外日志文件
 	logFiles = append(logFiles, proc.LogFiles...)
+
+	// 为每个日志文件确定显示前缀，以及备份文件名中时间戳的时区
+	prefixes := make(map[string]string, len(logFiles))
+	backupLocs := make(map[string]*time.Location, len(logFiles))
+	stderrLogFile, _ := GetStderrLogFile(proc)
+	for _, logFile := range logFiles {
+		prefixes[logFile] = getLogPrefix(proc, logFile, stderrLogFile, managedLogFiles, len(logFiles) > 1)
+		backupLocs[logFile] = time.Local
+	}
+	if !effectiveLogOptions(proc).LocalTime {
+		for _, logFile := range managedLogFiles {
+			backupLocs[logFile] = time.UTC
+		}
+	}
 	
 	if len(logFiles) == 0 {
 		fmt.Printf("📃 进程 '%s' 没有配置任何日志文件\n", proc.Name)
 		return nil
 	}
 
+	printLine := func(filename, text string) {
+		prefix := prefixes[filename]
+		if prefix != "" {
+			fmt.Printf("[%s] %s\n", prefix, text)
+		} else {
+			fmt.Println(text)
+		}
+	}
+
 	// 检查并启动所有日志文件的追踪
 	var wg sync.WaitGroup
 	var tails []*tail.Tail
 	
 	for _, logFile := range logFiles {
 		// 检查日志文件是否存在（仅作提示，不存在也会追踪等待创建）
-		if _, err := os.Stat(logFile); os.IsNotExist(err) {
+		_, statErr := os.Stat(logFile)
+		if !opts.Follow {
+			// 不追踪时只输出已有内容，保持输出干净以便重定向
+			if os.IsNotExist(statErr) && !opts.hasHistoryRange() {
+				continue
+			}
+		} else if os.IsNotExist(statErr) {
 			fmt.Printf("📃 日志文件不存在，等待创建: %s\n", logFile)
 		} else {
 			fmt.Printf("📃 找到日志文件: %s\n", logFile)
 		}
 
+		tailConfig := tail.Config{
+			ReOpen:    opts.Follow, // 文件被移动或删除时重新打开
+			Follow:    opts.Follow, // 类似 tail -f
+			MustExist: false,       // 文件不存在时等待创建
+		}
+
+		// 输出历史，之后从已读取的位置继续追踪
+		if opts.hasHistoryRange() {
+			history, offset, err := readLogHistory(logFile, backupLocs[logFile], opts)
+			if err != nil {
+				fmt.Printf("⚠️ 读取日志文件 '%s' 的历史失败: %v\n", logFile, err)
+			}
+			for _, line := range history {
+				printLine(logFile, line)
+			}
+			if !opts.Follow {
+				continue
+			}
+			tailConfig.Location = &tail.SeekInfo{Offset: offset, Whence: io.SeekStart}
+			tailConfig.Logger = tail.DiscardingLogger // 不输出 tail 库的 "Seeked" 提示
+		}
+
 		// 使用 tail 库追踪日志文件
-		t, err := tail.TailFile(logFile, tail.Config{
-			ReOpen:    true,  // 文件被移动或删除时重新打开
-			Follow:    true,  // 类似 tail -f
-			MustExist: false, // 文件不存在时等待创建
-		})
+		t, err := tail.TailFile(logFile, tailConfig)
 
 		if err != nil {
 			fmt.Printf("⚠️ 无法开始追踪日志文件 '%s': %v\n", logFile, err)
@@ -59,17 +109,21 @@ func TailLog(proc config.Process) error {
 		wg.Add(1)
 		go func(t *tail.Tail, filename string) {
 			defer wg.Done()
+			filter := newLineFilter(opts)
 			for line := range t.Lines {
-				prefix := getLogPrefix(filename, len(logFiles) > 1)
-				if prefix != "" {
-					fmt.Printf("[%s] %s\n", prefix, line.Text)
-				} else {
-					fmt.Println(line.Text)
+				if filter.Match(line.Text) {
+					printLine(filename, line.Text)
 				}
 			}
 		}(t, logFile)
 	}
 
+	if !opts.Follow {
+		// 等待所有文件读取完毕
+		wg.Wait()
+		return nil
+	}
+
 	if len(tails) == 0 {
 		return fmt.Errorf("无法追踪任何日志文件")
 	}
@@ -83,18 +137,22 @@ func TailLog(proc config.Process) error {
 }
 
 // getLogPrefix 根据文件路径生成合适的日志前缀
-func getLogPrefix(filename string, multipleFiles bool) string {
+func getLogPrefix(proc config.Process, filename string, stderrLogFile string, managedLogFiles []string, multipleFiles bool) string {
 	// 如果只有一个文件，不显示前缀
 	if !multipleFiles {
 		return ""
 	}
 	
 	// 检查是否是procmate管理的日志文件
-	if strings.Contains(filename, "/logs/") && strings.HasSuffix(filename, ".log") {
+	for _, managed := range managedLogFiles {
+		if filename != managed {
+			continue
+		}
 		// 对于procmate日志，使用进程名而不是通用的"procmate"标识
-		baseName := filepath.Base(filename)
-		processName := strings.TrimSuffix(baseName, ".log")
-		return "stdout/" + processName // 表示这是进程的stdout/stderr输出
+		if filename == stderrLogFile {
+			return "stderr/" + proc.Name // 表示这是进程单独记录的stderr输出
+		}
+		return "stdout/" + proc.Name // 表示这是进程的stdout/stderr输出
 	}
 	
 	// 对于用户自定义的日志文件，使用完整文件名
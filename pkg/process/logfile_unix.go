@@ -0,0 +1,14 @@
+//go:build !windows
+
+package process
+
+import (
+	"os"
+	"syscall"
+)
+
+// openLogFile 以追加方式打开 (必要时创建) 日志文件。
+// 使用 O_NOFOLLOW，日志路径是符号链接时直接失败，而不是写入链接指向的文件。
+func openLogFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND|syscall.O_NOFOLLOW, 0640)
+}
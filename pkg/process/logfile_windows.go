@@ -0,0 +1,10 @@
+//go:build windows
+
+package process
+
+import "os"
+
+// openLogFile 以追加方式打开 (必要时创建) 日志文件。
+func openLogFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+}
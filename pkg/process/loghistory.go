@@ -0,0 +1,352 @@
+package process
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// lumberjack 备份文件名中的时间格式：<name>-2006-01-02T15-04-05.000.log[.gz]
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// TailOptions 控制日志的历史输出和追踪方式。
+type TailOptions struct {
+	Lines  int            // 输出最近 N 行历史，0 表示不限制
+	Since  time.Time      // 只输出该时间之后的历史，零值表示不限制
+	Follow bool           // 输出历史后是否继续追踪 (类似 tail -f)
+	Grep   *regexp.Regexp // 只输出匹配的行，nil 表示不过滤
+	Level  string         // 只输出不低于该级别的行，空表示不过滤
+}
+
+// hasHistoryRange 判断是否指定了历史范围 (-n 或 --since)。
+// 未指定时保持原有行为：从当前日志文件开头输出。
+func (o TailOptions) hasHistoryRange() bool {
+	return o.Lines > 0 || !o.Since.IsZero()
+}
+
+// ParseSince 解析 --since 参数，支持相对时长 (10m、2h) 和本地时间 (2026-10-01T10:00)。
+func ParseSince(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	layouts := []string{
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间 '%s'，示例: 10m、2h、2026-10-01T10:00", s)
+}
+
+// levelRanks 定义日志级别的高低，用于 --level 过滤。
+var levelRanks = map[string]int{
+	"TRACE":    0,
+	"DEBUG":    1,
+	"INFO":     2,
+	"WARN":     3,
+	"WARNING":  3,
+	"ERROR":    4,
+	"ERR":      4,
+	"FATAL":    5,
+	"PANIC":    5,
+	"CRITICAL": 5,
+}
+
+// ParseLevel 校验 --level 参数并返回其标准写法。
+func ParseLevel(level string) (string, error) {
+	upper := strings.ToUpper(level)
+	if _, ok := levelRanks[upper]; !ok {
+		return "", fmt.Errorf("未知的日志级别 '%s'，可选值: debug / info / warn / error / fatal", level)
+	}
+	return upper, nil
+}
+
+var (
+	levelFieldPattern = regexp.MustCompile(`(?i)\blevel[=:]\s*"?([a-z]+)`)
+	levelTokenPattern = regexp.MustCompile(`\b(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|ERR|FATAL|PANIC|CRITICAL)\b`)
+)
+
+// detectLevel 从一行日志中识别日志级别，无法识别时返回空字符串。
+// 依次尝试 JSON 的 level 字段、level=xxx 形式，以及大写的级别关键字。
+func detectLevel(line string) string {
+	if strings.HasPrefix(line, "{") {
+		var fields map[string]interface{}
+		if json.Unmarshal([]byte(line), &fields) == nil {
+			for _, key := range []string{"level", "lvl", "severity"} {
+				if v, ok := fields[key].(string); ok {
+					return strings.ToUpper(v)
+				}
+			}
+			if msg, ok := fields["message"].(string); ok {
+				line = msg
+			}
+		}
+	}
+	if m := levelFieldPattern.FindStringSubmatch(line); m != nil {
+		return strings.ToUpper(m[1])
+	}
+	if m := levelTokenPattern.FindString(line); m != "" {
+		return m
+	}
+	return ""
+}
+
+// lineTimeLayouts 是识别行首时间戳时尝试的格式。
+var lineTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.000",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05.000",
+	"2006-01-02T15:04:05",
+	"2006/01/02 15:04:05",
+}
+
+// parseLineTime 识别一行日志的时间戳，支持 JSON 的 time 字段和常见的行首时间格式。
+func parseLineTime(line string) (time.Time, bool) {
+	if strings.HasPrefix(line, "{") {
+		var fields map[string]interface{}
+		if json.Unmarshal([]byte(line), &fields) == nil {
+			for _, key := range []string{"time", "ts", "timestamp", "@timestamp"} {
+				if v, ok := fields[key].(string); ok {
+					if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+						return t, true
+					}
+				}
+			}
+		}
+		return time.Time{}, false
+	}
+
+	line = strings.TrimLeft(line, "[")
+	for _, layout := range lineTimeLayouts {
+		if layout == time.RFC3339Nano {
+			// RFC3339 长度不固定，取第一个字段
+			field, _, _ := strings.Cut(line, " ")
+			if t, err := time.Parse(layout, strings.TrimRight(field, "]")); err == nil {
+				return t, true
+			}
+			continue
+		}
+		if len(line) < len(layout) {
+			continue
+		}
+		if t, err := time.ParseInLocation(layout, line[:len(layout)], time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// lineFilter 按 --since / --grep / --level 过滤日志行。
+// 没有时间戳或级别的行 (例如异常堆栈) 沿用上一行的判断结果，保证多行日志完整输出。
+type lineFilter struct {
+	opts      TailOptions
+	lastTime  time.Time
+	lastLevel string
+}
+
+// newLineFilter 为一个日志文件创建过滤器，每个文件需要独立的过滤器。
+func newLineFilter(opts TailOptions) *lineFilter {
+	return &lineFilter{opts: opts}
+}
+
+// Match 判断一行日志是否应该输出。
+func (f *lineFilter) Match(line string) bool {
+	if !f.opts.Since.IsZero() {
+		if t, ok := parseLineTime(line); ok {
+			f.lastTime = t
+		}
+		if !f.lastTime.IsZero() && f.lastTime.Before(f.opts.Since) {
+			return false
+		}
+	}
+
+	if f.opts.Level != "" {
+		if level := detectLevel(line); level != "" {
+			if _, known := levelRanks[level]; known {
+				f.lastLevel = level
+			}
+		}
+		if f.lastLevel == "" || levelRanks[f.lastLevel] < levelRanks[f.opts.Level] {
+			return false
+		}
+	}
+
+	if f.opts.Grep != nil && !f.opts.Grep.MatchString(line) {
+		return false
+	}
+	return true
+}
+
+// logBackup 表示一个由 lumberjack 轮转出的备份文件。
+type logBackup struct {
+	Path      string
+	RotatedAt time.Time // 轮转时间，即该文件最后一行的大致写入时间
+}
+
+// listLogBackups 列出日志文件的所有轮转备份 (包括 .gz)，按轮转时间从旧到新排序。
+// loc 为备份文件名中时间戳的时区 (lumberjack 的 localTime 为 false 时使用 UTC)。
+func listLogBackups(logFile string, loc *time.Location) []logBackup {
+	dir := filepath.Dir(logFile)
+	ext := filepath.Ext(logFile)
+	prefix := strings.TrimSuffix(filepath.Base(logFile), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var backups []logBackup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(name, prefix)
+		ts = strings.TrimSuffix(ts, ".gz")
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		ts = strings.TrimSuffix(ts, ext)
+		t, err := time.ParseInLocation(backupTimeFormat, ts, loc)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, logBackup{Path: filepath.Join(dir, name), RotatedAt: t})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].RotatedAt.Before(backups[j].RotatedAt)
+	})
+	return backups
+}
+
+// scanLogFile 逐行读取日志文件 (自动解压 .gz)，最多读取 limit 字节 (<0 表示读到结尾)。
+// 返回实际读取的字节数，供之后从该位置继续追踪。
+func scanLogFile(path string, limit int64, fn func(line string)) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if limit >= 0 {
+		r = io.LimitReader(f, limit)
+	}
+	counter := &countingReader{r: r}
+	r = counter
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return 0, fmt.Errorf("解压 %s 失败: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			fn(strings.TrimRight(line, "\r\n"))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return counter.n, err
+		}
+	}
+	return counter.n, nil
+}
+
+// countingReader 记录已读取的字节数。
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// readLogHistory 读取日志文件 (指定了历史范围时包括其轮转备份) 中符合条件的历史行。
+// 返回历史行，以及当前日志文件已读取到的位置，之后的追踪从该位置开始。
+func readLogHistory(logFile string, backupLoc *time.Location, opts TailOptions) ([]string, int64, error) {
+	// 记录当前文件的大小，只读取到这里，之后的内容交给追踪
+	var size int64
+	if st, err := os.Stat(logFile); err == nil {
+		size = st.Size()
+	}
+
+	// 待读取的文件：满足时间范围的备份 + 当前文件，从旧到新
+	// 未指定历史范围时只读取当前文件
+	var files []string
+	if opts.hasHistoryRange() {
+		for _, b := range listLogBackups(logFile, backupLoc) {
+			if !opts.Since.IsZero() && b.RotatedAt.Before(opts.Since) {
+				continue
+			}
+			files = append(files, b.Path)
+		}
+	}
+
+	// 从最新的文件开始倒序读取，收集到足够的行数后停止
+	var history []string
+	var offset int64
+	for i := len(files); i >= 0; i-- {
+		var lines []string
+		filter := newLineFilter(opts)
+		collect := func(line string) {
+			if !filter.Match(line) {
+				return
+			}
+			lines = append(lines, line)
+			// 只保留最后 N 行，限制内存占用
+			if opts.Lines > 0 && len(lines) > 2*opts.Lines {
+				lines = append([]string(nil), lines[len(lines)-opts.Lines:]...)
+			}
+		}
+
+		if i == len(files) {
+			n, err := scanLogFile(logFile, size, collect)
+			if err != nil && !os.IsNotExist(err) {
+				return nil, 0, err
+			}
+			offset = n
+		} else if _, err := scanLogFile(files[i], -1, collect); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ 读取日志备份 %s 失败: %v\n", files[i], err)
+			continue
+		}
+
+		history = append(lines, history...)
+		if opts.Lines > 0 && len(history) >= opts.Lines {
+			break
+		}
+	}
+
+	if opts.Lines > 0 && len(history) > opts.Lines {
+		history = history[len(history)-opts.Lines:]
+	}
+	return history, offset, nil
+}
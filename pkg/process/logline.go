@@ -0,0 +1,143 @@
+package process
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"procmate/pkg/config"
+)
+
+const (
+	// maxLogLineBytes 是单行日志的最大长度，超过后会被拆分为多行，避免无换行的输出占满内存。
+	maxLogLineBytes = 64 * 1024
+
+	logFormatPlain = "plain"
+	logFormatJSON  = "json"
+)
+
+// usesLinePipeline 判断进程的日志是否需要按行处理。
+func usesLinePipeline(proc config.Process) bool {
+	return proc.LogTimestamps || proc.LogFormat == logFormatJSON
+}
+
+// validateLogFormat 校验 log_format 配置。
+func validateLogFormat(format string) error {
+	switch format {
+	case "", logFormatPlain, logFormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("不支持的 log_format '%s'，可选值: plain / json", format)
+	}
+}
+
+// logLine 是 json 格式下每行日志的结构。
+type logLine struct {
+	Time    string `json:"time"`
+	Process string `json:"process"`
+	Stream  string `json:"stream"`
+	Message string `json:"message"`
+	Partial bool   `json:"partial,omitempty"` // 超长行被拆分时，除最后一段外均为 true
+}
+
+// lineWriter 将子进程的原始输出按行拆分，并为每一行加上时间戳、输出流和进程名。
+// - 不完整的行会被缓存，直到收到换行符或调用 Flush。
+// - 超过 maxLogLineBytes 的行会被拆分，单行占用的内存有上限。
+type lineWriter struct {
+	mu      sync.Mutex
+	out     io.Writer
+	process string
+	stream  string
+	format  string
+	buf     []byte
+}
+
+// newLineWriter 创建按行处理的写入器，out 通常为 lumberjack.Logger。
+func newLineWriter(out io.Writer, process, stream, format string) *lineWriter {
+	return &lineWriter{out: out, process: process, stream: stream, format: format}
+}
+
+// Write 实现 io.Writer。返回值始终为 len(p)，写入底层失败时返回错误。
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		if err := w.emit(w.buf[:idx], false); err != nil {
+			return len(p), err
+		}
+		w.buf = w.buf[idx+1:]
+	}
+
+	// 超长且没有换行的内容，按最大长度拆分输出
+	for len(w.buf) >= maxLogLineBytes {
+		if err := w.emit(w.buf[:maxLogLineBytes], true); err != nil {
+			return len(p), err
+		}
+		w.buf = w.buf[maxLogLineBytes:]
+	}
+
+	// 收缩缓冲区，避免长期持有已输出内容的底层数组
+	if len(w.buf) == 0 {
+		w.buf = nil
+	} else if cap(w.buf) > 2*maxLogLineBytes {
+		w.buf = append([]byte(nil), w.buf...)
+	}
+	return len(p), nil
+}
+
+// Flush 输出缓存中剩余的不完整行，在输出流结束时调用。
+func (w *lineWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.emit(w.buf, false)
+	w.buf = nil
+	return err
+}
+
+// emit 格式化并写出一行。
+func (w *lineWriter) emit(line []byte, partial bool) error {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	now := time.Now().Format(time.RFC3339)
+
+	var out []byte
+	if w.format == logFormatJSON {
+		data, err := json.Marshal(logLine{
+			Time:    now,
+			Process: w.process,
+			Stream:  w.stream,
+			Message: string(line),
+			Partial: partial,
+		})
+		if err != nil {
+			return err
+		}
+		out = append(data, '\n')
+	} else {
+		out = make([]byte, 0, len(now)+len(w.process)+len(w.stream)+len(line)+8)
+		out = append(out, now...)
+		out = append(out, ' ')
+		out = append(out, w.stream...)
+		out = append(out, ' ')
+		out = append(out, w.process...)
+		out = append(out, " | "...)
+		out = append(out, line...)
+		out = append(out, '\n')
+	}
+
+	// 每行只调用一次 Write，合并 stdout/stderr 时行与行之间不会交错
+	_, err := w.out.Write(out)
+	return err
+}
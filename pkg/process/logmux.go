@@ -0,0 +1,353 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"procmate/pkg/config"
+
+	"github.com/hpcloud/tail"
+)
+
+// logReorderWindow 是同时追踪多个日志文件时的排序窗口。
+// 新的日志行会在窗口内暂存，按时间戳排序后再输出，避免不同文件的行因读取先后而交错。
+const logReorderWindow = 200 * time.Millisecond
+
+// logPrefixColors 是多进程日志前缀轮流使用的颜色 (ANSI 前景色)。
+var logPrefixColors = []string{"36", "33", "32", "35", "34", "96", "93", "92", "95", "94"}
+
+// logSource 表示多进程日志中的一个日志文件。
+type logSource struct {
+	Process   string         // 所属进程名
+	Label     string         // 显示的前缀，例如 api、api/stderr、api/app.log
+	Color     string         // 前缀颜色，同一个进程的所有文件使用相同的颜色
+	Path      string         // 日志文件路径
+	BackupLoc *time.Location // 轮转备份文件名中时间戳的时区
+}
+
+// muxLine 是多进程日志中的一行。
+type muxLine struct {
+	Source *logSource
+	Text   string
+	At     time.Time // 排序使用的时间
+}
+
+// logSourcesOf 列出进程的所有日志文件 (托管的 stdout/stderr 以及 log_files)。
+func logSourcesOf(proc config.Process, color string) ([]*logSource, error) {
+	managedLogFiles, err := GetManagedLogFiles(proc)
+	if err != nil {
+		return nil, fmt.Errorf("无法获取 '%s' 的日志文件路径: %w", proc.Name, err)
+	}
+	stderrLogFile, _ := GetStderrLogFile(proc)
+
+	managedLoc := time.Local
+	if !effectiveLogOptions(proc).LocalTime {
+		managedLoc = time.UTC
+	}
+
+	var sources []*logSource
+	for _, logFile := range managedLogFiles {
+		label := proc.Name
+		if logFile == stderrLogFile {
+			label = proc.Name + "/stderr"
+		}
+		sources = append(sources, &logSource{Process: proc.Name, Label: label, Color: color, Path: logFile, BackupLoc: managedLoc})
+	}
+	for _, logFile := range proc.LogFiles {
+		label := proc.Name + "/" + filepath.Base(logFile)
+		sources = append(sources, &logSource{Process: proc.Name, Label: label, Color: color, Path: logFile, BackupLoc: time.Local})
+	}
+	return sources, nil
+}
+
+// useColor 判断是否输出颜色：标准输出为终端且未设置 NO_COLOR。
+func useColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// lineClock 为同一个日志文件的行计算排序时间。
+// - 能识别时间戳的行使用自身的时间戳。
+// - 其余的行 (例如异常堆栈) 沿用上一行的时间；文件中从未出现时间戳时使用 fallback。
+// - 同一个文件内的时间不会倒退，保证单个文件的行序不变。
+type lineClock struct {
+	last time.Time
+}
+
+func (c *lineClock) next(line string, fallback time.Time) time.Time {
+	at, ok := parseLineTime(line)
+	if !ok {
+		at = c.last
+		if at.IsZero() {
+			at = fallback
+		}
+	}
+	if at.Before(c.last) {
+		at = c.last
+	}
+	c.last = at
+	return at
+}
+
+// logMux 将多个日志文件的内容交织输出，每一行带有对齐并着色的来源前缀。
+type logMux struct {
+	sources []*logSource
+	width   int           // 前缀宽度，用于对齐
+	colored bool          // 是否输出颜色
+	emit    func(muxLine) // 输出一行，默认带前缀打印到终端
+}
+
+// newLogMux 为一组进程的所有日志文件创建 logMux，每个进程使用一种颜色。
+func newLogMux(procs []config.Process) (*logMux, error) {
+	m := &logMux{colored: useColor()}
+	for i, proc := range procs {
+		procSources, err := logSourcesOf(proc, logPrefixColors[i%len(logPrefixColors)])
+		if err != nil {
+			return nil, err
+		}
+		m.sources = append(m.sources, procSources...)
+	}
+	for _, src := range m.sources {
+		m.width = max(m.width, len(src.Label))
+	}
+	m.emit = m.printLine
+	return m, nil
+}
+
+// printLine 输出带前缀的一行日志。
+func (m *logMux) printLine(l muxLine) {
+	if m.colored {
+		fmt.Printf("\033[%sm%-*s |\033[0m %s\n", l.Source.Color, m.width, l.Source.Label, l.Text)
+	} else {
+		fmt.Printf("%-*s | %s\n", m.width, l.Source.Label, l.Text)
+	}
+}
+
+// follow 追踪所有日志文件，offsets 中记录了位置的文件从该位置开始，其余文件从头开始。
+// 返回成功追踪的文件数、所有输出完成时关闭的通道，以及停止追踪的函数 (会等待缓存的行输出完毕)。
+func (m *logMux) follow(offsets map[*logSource]int64, opts TailOptions) (int, <-chan struct{}, func()) {
+	lines := make(chan muxLine, 256)
+	var wg sync.WaitGroup
+	var tails []*tail.Tail
+	for _, src := range m.sources {
+		tailConfig := tail.Config{
+			ReOpen:    true,
+			Follow:    true,
+			MustExist: false,
+			Logger:    tail.DiscardingLogger,
+		}
+		if offset, ok := offsets[src]; ok {
+			tailConfig.Location = &tail.SeekInfo{Offset: offset, Whence: io.SeekStart}
+		}
+		t, err := tail.TailFile(src.Path, tailConfig)
+		if err != nil {
+			fmt.Printf("⚠️ 无法开始追踪日志文件 '%s': %v\n", src.Path, err)
+			continue
+		}
+		tails = append(tails, t)
+
+		wg.Add(1)
+		go func(t *tail.Tail, src *logSource) {
+			defer wg.Done()
+			filter := newLineFilter(opts)
+			clock := &lineClock{}
+			for line := range t.Lines {
+				if filter.Match(line.Text) {
+					lines <- muxLine{Source: src, Text: line.Text, At: clock.next(line.Text, line.Time)}
+				}
+			}
+		}(t, src)
+	}
+
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		printReordered(lines, m.emit)
+	}()
+
+	stop := func() {
+		for _, t := range tails {
+			t.Stop()
+			t.Cleanup()
+		}
+		<-done
+	}
+	return len(tails), done, stop
+}
+
+// history 读取所有日志文件的历史，按时间合并排序。
+// 返回历史行，以及每个文件已读取到的位置 (之后的追踪从该位置开始)。
+func (m *logMux) history(opts TailOptions) ([]muxLine, map[*logSource]int64) {
+	var history []muxLine
+	offsets := make(map[*logSource]int64, len(m.sources))
+	for _, src := range m.sources {
+		if _, err := os.Stat(src.Path); os.IsNotExist(err) && !opts.hasHistoryRange() {
+			if opts.Follow {
+				fmt.Printf("📃 日志文件不存在，等待创建: %s\n", src.Path)
+			}
+			continue
+		}
+		lines, offset, err := readLogHistory(src.Path, src.BackupLoc, opts)
+		if err != nil {
+			fmt.Printf("⚠️ 读取日志文件 '%s' 的历史失败: %v\n", src.Path, err)
+			continue
+		}
+		offsets[src] = offset
+
+		// 开头没有时间戳的行使用文件中第一个时间戳
+		var first time.Time
+		for _, line := range lines {
+			if t, ok := parseLineTime(line); ok {
+				first = t
+				break
+			}
+		}
+		clock := &lineClock{}
+		for _, line := range lines {
+			history = append(history, muxLine{Source: src, Text: line, At: clock.next(line, first)})
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].At.Before(history[j].At)
+	})
+	if opts.Lines > 0 && len(history) > opts.Lines {
+		history = history[len(history)-opts.Lines:]
+	}
+	return history, offsets
+}
+
+// TailLogs 同时追踪多个进程的日志，类似 docker-compose logs。
+// 每一行带有对齐并着色的来源前缀；历史部分按时间戳合并排序，追踪部分在一个短暂的窗口内排序后输出。
+// 只有一个进程时等同于 TailLog。
+func TailLogs(procs []config.Process, opts TailOptions) error {
+	if len(procs) == 1 {
+		return TailLog(procs[0], opts)
+	}
+
+	m, err := newLogMux(procs)
+	if err != nil {
+		return err
+	}
+	if len(m.sources) == 0 {
+		fmt.Println("📃 所选进程没有配置任何日志文件")
+		return nil
+	}
+
+	// === 1. 输出历史：合并所有文件，按时间排序 ===
+	history, offsets := m.history(opts)
+	for _, l := range history {
+		m.emit(l)
+	}
+
+	if !opts.Follow {
+		return nil
+	}
+
+	// === 2. 继续追踪所有文件，经排序窗口输出 ===
+	count, done, _ := m.follow(offsets, opts)
+	if count == 0 {
+		return fmt.Errorf("无法追踪任何日志文件")
+	}
+	fmt.Printf("👀 正在追踪 %d 个进程的 %d 个日志文件，按 Ctrl+C 退出\n", len(procs), count)
+	<-done
+	return nil
+}
+
+// StreamLogs 从头开始追踪一组进程的日志并带前缀输出到终端，用于前台运行模式。
+// 返回的函数用于停止追踪，会等待已读取的行输出完毕。
+func StreamLogs(procs []config.Process) (func(), error) {
+	m, err := newLogMux(procs)
+	if err != nil {
+		return nil, err
+	}
+	_, _, stop := m.follow(nil, TailOptions{Follow: true})
+	return stop, nil
+}
+
+// LogLine 是日志流中的一行，用于控制接口。
+type LogLine struct {
+	Process string `json:"process"`
+	Source  string `json:"source"` // 日志来源，例如 api、api/stderr、api/app.log
+	Line    string `json:"line"`
+}
+
+// StreamLogLines 读取一组进程的日志历史，opts.Follow 为 true 时继续追踪直到 ctx 结束。
+// 每一行依次交给 fn 处理，fn 不会被并发调用。
+func StreamLogLines(ctx context.Context, procs []config.Process, opts TailOptions, fn func(LogLine)) error {
+	m, err := newLogMux(procs)
+	if err != nil {
+		return err
+	}
+	m.emit = func(l muxLine) {
+		fn(LogLine{Process: l.Source.Process, Source: l.Source.Label, Line: l.Text})
+	}
+
+	history, offsets := m.history(opts)
+	for _, l := range history {
+		m.emit(l)
+	}
+	if !opts.Follow {
+		return nil
+	}
+
+	_, done, stop := m.follow(offsets, opts)
+	select {
+	case <-ctx.Done():
+		stop()
+	case <-done:
+	}
+	return nil
+}
+
+// printReordered 从 lines 读取日志行，在 logReorderWindow 内按时间排序后输出，直到 lines 关闭。
+func printReordered(lines <-chan muxLine, printLine func(muxLine)) {
+	type pendingLine struct {
+		muxLine
+		arrived time.Time
+	}
+	var pending []pendingLine
+
+	// flush 按时间顺序输出已在窗口中停留足够久的行；all 为 true 时输出全部
+	flush := func(all bool) {
+		sort.SliceStable(pending, func(i, j int) bool {
+			return pending[i].At.Before(pending[j].At)
+		})
+		deadline := time.Now().Add(-logReorderWindow)
+		n := 0
+		for n < len(pending) && (all || !pending[n].arrived.After(deadline)) {
+			printLine(pending[n].muxLine)
+			n++
+		}
+		pending = append(pending[:0], pending[n:]...)
+	}
+
+	ticker := time.NewTicker(logReorderWindow / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				flush(true)
+				return
+			}
+			pending = append(pending, pendingLine{muxLine: l, arrived: time.Now()})
+		case <-ticker.C:
+			if len(pending) > 0 {
+				flush(false)
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"sync"
+	"syscall"
+
+	"procmate/pkg/config"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// LogHelperArg 是 procmate 日志中转进程的参数名。
+//
+// 子进程的 stdout/stderr 连接到管道，管道的读取端交给一个独立的 `procmate __log <spec>` 进程，
+// 由它负责按行处理和日志轮转。这样发起启动的 procmate 命令退出后，子进程的输出管道依然有人读取，
+// 不会因为 SIGPIPE 而退出；子进程 (及其所有后代) 关闭输出后，中转进程自动退出。
+const LogHelperArg = "__log"
+
+// useLogShim 判断当前平台是否使用日志中转进程。
+// Windows 不支持向子进程传递额外的文件描述符，仍由 procmate 进程自身写日志。
+var useLogShim = runtime.GOOS != "windows"
+
+// logShimSpec 描述日志中转进程的工作方式，以 JSON 形式通过命令行参数传递。
+type logShimSpec struct {
+	Process string            `json:"process"`
+	Format  string            `json:"format"`
+	Lines   bool              `json:"lines"` // 是否按行处理
+	Options config.LogOptions `json:"options"`
+	Streams []logShimStream   `json:"streams"` // 第 i 个输出流对应文件描述符 3+i
+}
+
+// logShimStream 描述一个输出流及其写入的日志文件。
+type logShimStream struct {
+	Stream string `json:"stream"` // stdout 或 stderr
+	Path   string `json:"path"`
+}
+
+// logPipes 保存子进程输出管道的两端。
+type logPipes struct {
+	spec    logShimSpec
+	readers []*os.File // 交给日志中转进程
+	writers []*os.File // 交给子进程
+	Stdout  *os.File
+	Stderr  *os.File
+}
+
+// openLogPipes 根据进程配置创建输出管道。
+// - 按行处理时，stdout 与 stderr 始终使用独立的管道，以便标记输出流。
+// - 否则 stderr 合并到 stdout 时两者共用一个管道。
+func openLogPipes(proc config.Process) (*logPipes, error) {
+	if err := validateLogFormat(proc.LogFormat); err != nil {
+		return nil, err
+	}
+
+	stdoutPath, err := GetLogFile(proc)
+	if err != nil {
+		return nil, err
+	}
+	stderrPath, err := GetStderrLogFile(proc)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := usesLinePipeline(proc)
+	spec := logShimSpec{
+		Process: proc.Name,
+		Format:  proc.LogFormat,
+		Lines:   lines,
+		Options: effectiveLogOptions(proc),
+		Streams: []logShimStream{{Stream: "stdout", Path: stdoutPath}},
+	}
+	if stderrPath != "" {
+		spec.Streams = append(spec.Streams, logShimStream{Stream: "stderr", Path: stderrPath})
+	} else if lines {
+		spec.Streams = append(spec.Streams, logShimStream{Stream: "stderr", Path: stdoutPath})
+	}
+
+	// 在启动子进程前检查日志文件可以写入，中转进程的错误无处报告
+	for _, s := range spec.Streams {
+		f, err := openLogFile(s.Path)
+		if err != nil {
+			return nil, fmt.Errorf("打开日志文件 '%s' 失败: %w", s.Path, err)
+		}
+		f.Close()
+	}
+
+	pipes := &logPipes{spec: spec}
+	for range spec.Streams {
+		r, w, err := os.Pipe()
+		if err != nil {
+			pipes.Close()
+			return nil, fmt.Errorf("创建日志管道失败: %w", err)
+		}
+		pipes.readers = append(pipes.readers, r)
+		pipes.writers = append(pipes.writers, w)
+	}
+
+	pipes.Stdout = pipes.writers[0]
+	pipes.Stderr = pipes.writers[len(pipes.writers)-1]
+	return pipes, nil
+}
+
+// startShim 启动日志中转进程，并关闭当前进程持有的管道两端。
+// 必须在子进程启动之后调用。
+func (p *logPipes) startShim() error {
+	defer p.Close()
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("获取 procmate 可执行文件路径失败: %w", err)
+	}
+	data, err := json.Marshal(p.spec)
+	if err != nil {
+		return err
+	}
+
+	shim := exec.Command(self, LogHelperArg, string(data))
+	shim.ExtraFiles = p.readers
+	shim.SysProcAttr = detachedSysProcAttr()
+	if err := shim.Start(); err != nil {
+		return fmt.Errorf("启动日志中转进程失败: %w", err)
+	}
+	// 中转进程独立运行，不等待其退出
+	return shim.Process.Release()
+}
+
+// Close 关闭当前进程持有的所有管道端。
+func (p *logPipes) Close() {
+	for _, f := range append(p.readers, p.writers...) {
+		f.Close()
+	}
+}
+
+// RunLogHelper 是日志中转进程的入口，由 main 在解析命令行之前调用。
+// 从文件描述符 3、4 读取子进程输出，写入按配置轮转的日志文件，所有输出流关闭后退出。
+func RunLogHelper(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "procmate: 日志中转进程参数错误")
+		os.Exit(2)
+	}
+	var spec logShimSpec
+	if err := json.Unmarshal([]byte(args[0]), &spec); err != nil {
+		fmt.Fprintf(os.Stderr, "procmate: 解析日志中转配置失败: %v\n", err)
+		os.Exit(2)
+	}
+
+	// 中转进程的生命周期只取决于子进程的输出，不响应终端信号
+	signal.Ignore(syscall.SIGINT, syscall.SIGHUP)
+
+	// 同一路径的输出流共用一个 lumberjack.Logger
+	loggers := make(map[string]*lumberjack.Logger)
+	var wg sync.WaitGroup
+	for i, s := range spec.Streams {
+		logger, ok := loggers[s.Path]
+		if !ok {
+			var err error
+			logger, err = newLogWriter(s.Path, spec.Options)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "procmate: %v\n", err)
+				os.Exit(1)
+			}
+			loggers[s.Path] = logger
+		}
+
+		in := os.NewFile(uintptr(3+i), s.Stream)
+		wg.Add(1)
+		go func(in *os.File, stream string, out io.Writer) {
+			defer wg.Done()
+			defer in.Close()
+			if !spec.Lines {
+				io.Copy(out, in)
+				return
+			}
+			lw := newLineWriter(out, spec.Process, stream, spec.Format)
+			io.Copy(lw, in)
+			lw.Flush()
+		}(in, s.Stream, logger)
+	}
+
+	wg.Wait()
+	for _, logger := range loggers {
+		logger.Close()
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,100 @@
+package process
+
+import (
+	"fmt"
+	"io"
+
+	"procmate/pkg/config"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// effectiveLogOptions 返回进程实际使用的日志轮转配置：
+// 以全局 'log_options' 为基础，叠加进程级 'log_options' 中配置了的字段。
+func effectiveLogOptions(proc config.Process) config.LogOptions {
+	opts := config.Current().Settings.LogOptions
+	override := proc.LogOptions
+	if override == nil {
+		return opts
+	}
+
+	if override.MaxSizeMB > 0 {
+		opts.MaxSizeMB = override.MaxSizeMB
+	}
+	if override.MaxBackups > 0 {
+		opts.MaxBackups = override.MaxBackups
+	}
+	if override.MaxAgeDays > 0 {
+		opts.MaxAgeDays = override.MaxAgeDays
+	}
+	if override.Compress != nil {
+		opts.Compress = *override.Compress
+	}
+	if override.LocalTime != nil {
+		opts.LocalTime = *override.LocalTime
+	}
+	return opts
+}
+
+// newLogWriter 创建一个按配置轮转的日志写入器。
+// 先以 O_NOFOLLOW 打开一次日志文件，拒绝写入已是符号链接的日志路径。
+// 这只是一次检查：lumberjack 随后 (以及每次轮转后) 按路径重新打开文件，不带 O_NOFOLLOW，
+// 因此日志所在的目录不能被其他用户写入，切换运行身份时由 checkLogDirNotWritable 保证。
+func newLogWriter(path string, opts config.LogOptions) (*lumberjack.Logger, error) {
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开日志文件 '%s' 失败: %w", path, err)
+	}
+	f.Close()
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    opts.MaxSizeMB,
+		MaxBackups: opts.MaxBackups,
+		MaxAge:     opts.MaxAgeDays,
+		Compress:   opts.Compress,
+		LocalTime:  opts.LocalTime,
+	}, nil
+}
+
+// openLogWriters 根据进程配置创建 stdout 和 stderr 的日志写入器，
+// 在不支持日志中转进程的平台上由 procmate 进程自身写日志。
+// - 关闭日志时两者均为 nil (子进程输出被丢弃)。
+// - stderr 合并时两者为同一个写入器。
+func openLogWriters(proc config.Process) (stdout io.Writer, stderr io.Writer, err error) {
+	if proc.DisableLog {
+		return nil, nil, nil
+	}
+
+	opts := effectiveLogOptions(proc)
+
+	stdoutPath, err := GetLogFile(proc)
+	if err != nil {
+		return nil, nil, err
+	}
+	stdoutWriter, err := newLogWriter(stdoutPath, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stderrPath, err := GetStderrLogFile(proc)
+	if err != nil {
+		return nil, nil, err
+	}
+	var stderrWriter io.Writer = stdoutWriter
+	if stderrPath != "" {
+		stderrWriter, err = newLogWriter(stderrPath, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// 按行处理时，为每个输出流分别包装，以便标记输出流
+	if usesLinePipeline(proc) {
+		if err := validateLogFormat(proc.LogFormat); err != nil {
+			return nil, nil, err
+		}
+		return newLineWriter(stdoutWriter, proc.Name, "stdout", proc.LogFormat),
+			newLineWriter(stderrWriter, proc.Name, "stderr", proc.LogFormat), nil
+	}
+	return stdoutWriter, stderrWriter, nil
+}
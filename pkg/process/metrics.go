@@ -0,0 +1,135 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"procmate/pkg/config"
+)
+
+// metricDesc 描述一个 Prometheus 指标。
+type metricDesc struct {
+	Name string
+	Type string // gauge 或 counter
+	Help string
+}
+
+var (
+	metricUp            = metricDesc{"procmate_process_up", "gauge", "进程是否在运行 (1 运行, 0 离线)"}
+	metricReady         = metricDesc{"procmate_process_ready", "gauge", "进程是否就绪 (1 就绪, 0 未就绪)"}
+	metricRestarts      = metricDesc{"procmate_process_restarts_total", "counter", "watch 自动重启进程的次数"}
+	metricLastExitCode  = metricDesc{"procmate_process_last_exit_code", "gauge", "进程最近一次的退出码，被信号终止时为 -1"}
+	metricCPUPercent    = metricDesc{"procmate_process_cpu_percent", "gauge", "进程当前的 CPU 使用率"}
+	metricCPUAvg1m      = metricDesc{"procmate_process_cpu_percent_avg_1m", "gauge", "进程最近 1 分钟的平均 CPU 使用率"}
+	metricCPUAvg5m      = metricDesc{"procmate_process_cpu_percent_avg_5m", "gauge", "进程最近 5 分钟的平均 CPU 使用率"}
+	metricRSSBytes      = metricDesc{"procmate_process_rss_bytes", "gauge", "进程的常驻内存 (RSS)"}
+	metricUptime        = metricDesc{"procmate_process_uptime_seconds", "gauge", "进程已运行的时间"}
+	metricStartDuration = metricDesc{"procmate_process_start_duration_seconds", "gauge", "进程最近一次从启动到就绪的耗时"}
+)
+
+// metricSample 是一个指标的一个取值。
+type metricSample struct {
+	Process string
+	Value   float64
+}
+
+// WriteMetrics 以 Prometheus 文本格式输出所有已启用进程的指标。
+func WriteMetrics(w io.Writer) error {
+	samples := make(map[metricDesc][]metricSample)
+	add := func(desc metricDesc, name string, value float64) {
+		samples[desc] = append(samples[desc], metricSample{Process: name, Value: value})
+	}
+
+	var procs []config.Process
+	for _, proc := range config.Current().Processes {
+		if proc.Enabled {
+			procs = append(procs, proc)
+		}
+	}
+	infos, err := GetProcessInfos(procs)
+	if err != nil {
+		return fmt.Errorf("获取进程信息失败: %w", err)
+	}
+
+	for i, proc := range procs {
+		info := infos[i]
+		st := GetProcessStats(proc.Name)
+		if info.TimedOut {
+			// 状态未知时不输出运行状态相关的指标
+			add(metricRestarts, proc.Name, float64(st.Restarts))
+			continue
+		}
+
+		add(metricUp, proc.Name, boolValue(info.IsRunning))
+		add(metricReady, proc.Name, boolValue(info.IsReady))
+		add(metricRestarts, proc.Name, float64(st.Restarts))
+		if st.HasExited {
+			add(metricLastExitCode, proc.Name, float64(st.LastExitCode))
+		}
+		if info.IsRunning {
+			add(metricCPUPercent, proc.Name, info.CPUPercent)
+			if info.CPUAvg1m != nil && info.CPUAvg5m != nil {
+				add(metricCPUAvg1m, proc.Name, *info.CPUAvg1m)
+				add(metricCPUAvg5m, proc.Name, *info.CPUAvg5m)
+			}
+			add(metricRSSBytes, proc.Name, info.MemoryRSS*1024*1024)
+			add(metricUptime, proc.Name, info.Uptime.Seconds())
+		}
+		if st.LastStartDuration > 0 {
+			add(metricStartDuration, proc.Name, st.LastStartDuration.Seconds())
+		}
+	}
+
+	var buf bytes.Buffer
+	descs := []metricDesc{
+		metricUp, metricReady, metricRestarts, metricLastExitCode,
+		metricCPUPercent, metricCPUAvg1m, metricCPUAvg5m, metricRSSBytes, metricUptime, metricStartDuration,
+	}
+	for _, desc := range descs {
+		fmt.Fprintf(&buf, "# HELP %s %s\n", desc.Name, desc.Help)
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", desc.Name, desc.Type)
+		for _, s := range samples[desc] {
+			fmt.Fprintf(&buf, "%s{process=\"%s\"} %g\n", desc.Name, escapeLabelValue(s.Process), s.Value)
+		}
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// boolValue 将布尔值转换为 1 或 0。
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// escapeLabelValue 按 Prometheus 文本格式转义标签值。
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// ServeMetrics 在 addr 上提供 /metrics 接口，返回的 Server 可用于关闭服务。
+// 监听失败时立即返回错误。
+func ServeMetrics(addr string) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("监听 %s 失败: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := WriteMetrics(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(ln)
+	return server, nil
+}
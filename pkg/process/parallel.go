@@ -42,6 +42,7 @@ type ParallelStartManager struct {
 	enableRollback       bool          // 是否启用失败回滚
 	showProgress         bool          // 是否显示进度信息
 	smartFailureHandling bool          // 是否启用智能失败处理
+	startOptions         StartOptions  // 每个进程的启动选项
 }
 
 // ParallelStartOptions 并行启动配置选项
@@ -53,6 +54,7 @@ type ParallelStartOptions struct {
 	EnableRollback   bool          // 是否在失败时回滚已启动的进程
 	ShowProgress     bool          // 是否显示启动进度
 	SmartFailureHandling bool      // 是否启用智能失败处理（仅停止依赖失败进程的进程）
+	ForceFreePort    bool          // 端口被之前由 procmate 启动的残留进程占用时，先停止该进程
 }
 
 // NewParallelStartManager 创建新的并行启动管理器
@@ -87,6 +89,7 @@ func NewParallelStartManager(options ParallelStartOptions) *ParallelStartManager
 		enableRollback:       options.EnableRollback,
 		showProgress:         options.ShowProgress,
 		smartFailureHandling: options.SmartFailureHandling,
+		startOptions:         StartOptions{ForceFreePort: options.ForceFreePort},
 	}
 }
 
@@ -344,7 +347,7 @@ func (m *ParallelStartManager) startSingleProcess(ctx context.Context, process c
 	isRunning, err := IsRunning(process)
 	if err == nil && isRunning {
 		// 进一步检查是否已就绪
-		if isReady, _ := IsReady(process); isReady {
+		if isReady, _ := CheckReady(process); isReady {
 			result.Success = true
 			result.IsSkipped = true
 			result.Duration = time.Since(startTime)
@@ -364,7 +367,7 @@ func (m *ParallelStartManager) startSingleProcess(ctx context.Context, process c
 	// 在协程中启动进程，以支持超时控制
 	done := make(chan error, 1)
 	go func() {
-		done <- Start(process)
+		done <- StartWithOptions(process, m.startOptions)
 	}()
 
 	// 等待进程启动完成或超时
@@ -376,6 +379,7 @@ func (m *ParallelStartManager) startSingleProcess(ctx context.Context, process c
 			result.Error = fmt.Errorf("启动进程 '%s' 失败: %w", process.Name, err)
 		} else {
 			result.Success = true
+			recordStartDuration(process.Name, result.Duration)
 			// 获取进程PID（如果可能）
 			if pid, err := ReadPid(process); err == nil {
 				result.PID = pid
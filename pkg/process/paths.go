@@ -1,21 +1,20 @@
 package process
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"procmate/pkg/config" // 引入 config 包以访问全局配置
 	"strconv"
+	"strings"
 )
 
 // ensureCommonRuntimeDir 确保运行时目录存在，并返回其路径。
 // 如果未配置 runtime_dir，则默认使用 /tmp/procmate。
 func ensureCommonRuntimeDir() (string, error) {
-	runtimeDir := config.Cfg.Settings.RuntimeDir
-	if runtimeDir == "" {
-		// 防御性编程：用户没设置时给一个默认值
-		runtimeDir = "/tmp/procmate"
-	}
+	// 防御性编程：用户没设置时使用默认值
+	runtimeDir := config.RuntimeDir()
 
 	// 创建目录（递归创建父目录），如果已存在不会报错
 	if err := os.MkdirAll(runtimeDir, 0755); err != nil {
@@ -43,25 +42,122 @@ func getPidFile(proc config.Process) (string, error) {
 	return filepath.Join(pidDir, fmt.Sprintf("%s.pid", proc.Name)), nil
 }
 
-// GetLogFile 返回指定进程的日志文件路径。
-func GetLogFile(proc config.Process) (string, error) {
-	// 默认放在 runtime_dir 下
+// getReadyStateFile 返回指定进程的就绪检查进度文件路径。
+// 格式：<runtime_dir>/pids/<proc.Name>.ready
+func getReadyStateFile(proc config.Process) (string, error) {
+	pidFile, err := getPidFile(proc)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(pidFile, ".pid") + ".ready", nil
+}
+
+// getLockFile 返回指定进程的锁文件路径。
+// 格式：<runtime_dir>/locks/<proc.Name>.lock
+func getLockFile(proc config.Process) (string, error) {
 	runtimeDir, err := ensureCommonRuntimeDir()
 	if err != nil {
 		return "", err
 	}
 
-	// 构造 logs/<proc.Name> 路径
-	logDir := filepath.Join(runtimeDir, "logs", proc.Name)
+	lockDir := filepath.Join(runtimeDir, "locks")
+	if err := os.MkdirAll(lockDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create lock directory '%s': %w", lockDir, err)
+	}
 
-	// 确保目录存在
+	return filepath.Join(lockDir, fmt.Sprintf("%s.lock", proc.Name)), nil
+}
+
+// getLogDir 返回指定进程的默认日志目录并确保其存在。
+// 格式：<log_dir>/<proc.Name>，未配置 log_dir 时为 <runtime_dir>/logs/<proc.Name>
+func getLogDir(proc config.Process) (string, error) {
+	logRoot := config.Current().Settings.LogDir
+	if logRoot == "" {
+		// 默认放在 runtime_dir 下
+		runtimeDir, err := ensureCommonRuntimeDir()
+		if err != nil {
+			return "", err
+		}
+		logRoot = filepath.Join(runtimeDir, "logs")
+	}
+
+	logDir := filepath.Join(logRoot, proc.Name)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create log directory '%s': %w", logDir, err)
 	}
+	return logDir, nil
+}
 
+// ensureLogPath 确保自定义日志路径的父目录存在。
+func ensureLogPath(path string) (string, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create log directory '%s': %w", dir, err)
+	}
+	return path, nil
+}
+
+// GetLogFile 返回指定进程的日志文件路径 (stdout，合并模式下也包含 stderr)。
+func GetLogFile(proc config.Process) (string, error) {
+	if proc.StdoutLog != "" {
+		return ensureLogPath(proc.StdoutLog)
+	}
+
+	logDir, err := getLogDir(proc)
+	if err != nil {
+		return "", err
+	}
 	return filepath.Join(logDir, fmt.Sprintf("%s.log", proc.Name)), nil
 }
 
+// IsStderrSeparate 判断进程的 stderr 是否单独记录。
+// 显式配置了 merge_stderr 时以其为准，否则仅在配置了 stderr_log 时单独记录。
+func IsStderrSeparate(proc config.Process) bool {
+	if proc.MergeStderr != nil {
+		return !*proc.MergeStderr
+	}
+	return proc.StderrLog != ""
+}
+
+// GetStderrLogFile 返回指定进程 stderr 日志的路径。
+// stderr 合并到 stdout 日志时返回空字符串。
+func GetStderrLogFile(proc config.Process) (string, error) {
+	if !IsStderrSeparate(proc) {
+		return "", nil
+	}
+	if proc.StderrLog != "" {
+		return ensureLogPath(proc.StderrLog)
+	}
+
+	logDir, err := getLogDir(proc)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(logDir, fmt.Sprintf("%s.stderr.log", proc.Name)), nil
+}
+
+// GetManagedLogFiles 返回 procmate 为进程记录的所有日志文件 (stdout 以及单独记录时的 stderr)。
+// 关闭日志时返回空列表。
+func GetManagedLogFiles(proc config.Process) ([]string, error) {
+	if proc.DisableLog {
+		return nil, nil
+	}
+	stdoutLog, err := GetLogFile(proc)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{stdoutLog}
+
+	stderrLog, err := GetStderrLogFile(proc)
+	if err != nil {
+		return nil, err
+	}
+	if stderrLog != "" {
+		files = append(files, stderrLog)
+	}
+	return files, nil
+}
+
 // SavePid 保存进程 PID 到对应的 .pid 文件。
 func WritePid(proc config.Process, pid int) error {
 	pidFilePath, err := getPidFile(proc)
@@ -79,6 +175,7 @@ func WritePid(proc config.Process, pid int) error {
 }
 
 // ReadPid 读取进程的 PID，如果文件不存在或内容非法，返回错误。
+// 文件不存在时返回的错误包装了 ErrPidfileNotFound。
 func ReadPid(proc config.Process) (int, error) {
 	pidFile, err := getPidFile(proc)
 	if err != nil {
@@ -86,6 +183,9 @@ func ReadPid(proc config.Process) (int, error) {
 	}
 	data, err := os.ReadFile(pidFile)
 	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("%w: %s", ErrPidfileNotFound, pidFile)
+		}
 		return 0, err
 	}
 
@@ -0,0 +1,109 @@
+package process
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"procmate/pkg/config"
+
+	psnet "github.com/shirou/gopsutil/v3/net"
+)
+
+// checkPort 检查进程配置的 TCP 端口是否已由该进程 (或其子孙进程) 监听。
+// 端口被其他进程 (如之前运行残留的实例) 占用时不算就绪；配置了 host 时只认可监听在该地址或通配地址上的端口。
+// 无法读取主进程的 socket 信息时，退化为尝试连接 host:port。
+func checkPort(proc config.Process) (bool, error) {
+	port := proc.Port
+	if port < 0 || port > 65535 {
+		return false, fmt.Errorf("端口无效 %d", port)
+	}
+
+	pid, err := ReadPid(proc)
+	if err != nil {
+		return false, fmt.Errorf("读取 PID 失败: %w", err)
+	}
+	hostIPs, err := resolveHost(proc.Host)
+	if err != nil {
+		return false, err
+	}
+
+	// 先检查主进程，未找到时再检查子孙进程 (如由 shell 启动的服务)
+	listens := func(p int32) (bool, error) {
+		connections, err := psnet.ConnectionsPid("tcp", p)
+		if err != nil {
+			return false, err
+		}
+		for _, conn := range connections {
+			if conn.Status == "LISTEN" && int(conn.Laddr.Port) == port && listenAddrMatches(conn.Laddr.IP, hostIPs) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	if ok, err := listens(int32(pid)); err != nil {
+		return dialPort(proc.Host, port)
+	} else if ok {
+		return true, nil
+	}
+	if children, err := childProcesses(); err == nil {
+		for _, p := range descendantPids(children, int32(pid)) {
+			// 子孙进程可能已经退出，忽略错误
+			if ok, _ := listens(p); ok {
+				return true, nil
+			}
+		}
+	}
+	return false, fmt.Errorf("端口 %s 未被进程 %d 及其子进程监听", net.JoinHostPort(proc.Host, strconv.Itoa(port)), pid)
+}
+
+// resolveHost 解析配置的监听地址，为空时返回 nil 表示任意地址。
+func resolveHost(host string) ([]net.IP, error) {
+	if host == "" {
+		return nil, nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, fmt.Errorf("解析监听地址 %s 失败: %w", host, err)
+	}
+	return ips, nil
+}
+
+// listenAddrMatches 判断监听地址是否满足配置的地址。监听在通配地址 (0.0.0.0 / ::) 上时总是满足。
+func listenAddrMatches(addr string, hostIPs []net.IP) bool {
+	if hostIPs == nil {
+		return true
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.IsUnspecified() {
+		return true
+	}
+	for _, hostIP := range hostIPs {
+		if ip.Equal(hostIP) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialPort 尝试连接 host:port，能建立连接即视为就绪。
+func dialPort(host string, port int) (bool, error) {
+	conn, err := net.DialTimeout("tcp", portAddr(host, port), time.Second)
+	if err != nil {
+		return false, fmt.Errorf("连接 %s 失败: %w", portAddr(host, port), err)
+	}
+	conn.Close()
+	return true, nil
+}
+
+// portAddr 返回 host:port，未配置 host 时使用 127.0.0.1。
+func portAddr(host string, port int) string {
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
@@ -0,0 +1,217 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"procmate/pkg/config"
+
+	psnet "github.com/shirou/gopsutil/v3/net"
+	gops "github.com/shirou/gopsutil/v3/process"
+)
+
+// 由 procmate 启动的进程带有以下环境变量 (子孙进程会继承)，用于识别之前运行残留的进程。
+const (
+	envManagedProcess = "PROCMATE_PROCESS"
+	envRuntimeDir     = "PROCMATE_RUNTIME_DIR"
+)
+
+// managedEnv 返回标记进程由 procmate 启动的环境变量。
+func managedEnv(proc config.Process) []string {
+	return []string{
+		envManagedProcess + "=" + proc.Name,
+		envRuntimeDir + "=" + absRuntimeDir(),
+	}
+}
+
+// absRuntimeDir 返回 runtime_dir 的绝对路径，在不同工作目录下运行的 procmate 得到相同的结果。
+func absRuntimeDir() string {
+	dir := config.RuntimeDir()
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
+
+// portHolder 描述占用端口的进程。
+type portHolder struct {
+	PID     int32
+	Name    string // 进程名，无法获取时为空
+	Managed string // 由当前 runtime_dir 的 procmate 启动时为对应的进程名
+}
+
+func (h portHolder) String() string {
+	if h.PID == 0 {
+		return "未知进程"
+	}
+	if h.Name == "" {
+		return fmt.Sprintf("PID %d", h.PID)
+	}
+	return fmt.Sprintf("PID %d (%s)", h.PID, h.Name)
+}
+
+// checkPortConflict 在启动进程前检查其端口是否已被其他进程占用，占用时立即返回错误，而不是等到就绪超时。
+// 占用者是之前由 procmate 启动、现已不受管理的残留进程时，forceFreePort 为 true 会先停止它。
+func checkPortConflict(proc config.Process, forceFreePort bool) error {
+	if proc.Port <= 0 {
+		return nil
+	}
+	holders, err := findPortHolders(proc)
+	if err != nil {
+		// 无法获取端口信息 (如没有权限读取其他用户的连接) 时不阻止启动，由就绪检查兜底
+		return nil
+	}
+
+	for _, h := range holders {
+		if h.Managed == "" {
+			return fmt.Errorf("端口 %d 已被 %s 占用", proc.Port, h)
+		}
+		if live := liveManagedProcess(h); live != "" {
+			return fmt.Errorf("端口 %d 已被进程 '%s' 占用 (%s)", proc.Port, live, h)
+		}
+		if !forceFreePort {
+			return fmt.Errorf("端口 %d 已被 %s 占用，它是之前由 procmate 启动的 '%s' 的残留进程，可使用 --force-free-port 停止它",
+				proc.Port, h, h.Managed)
+		}
+		fmt.Printf("🔧 端口 %d 被 '%s' 的残留进程 %s 占用，正在停止...\n", proc.Port, h.Managed, h)
+		if err := terminatePid(int(h.PID), stopTimeout(proc)); err != nil {
+			return fmt.Errorf("停止占用端口 %d 的残留进程 %s 失败: %w", proc.Port, h, err)
+		}
+	}
+	return nil
+}
+
+// findPortHolders 返回监听 proc 的端口 (且地址与 proc 的 host 冲突) 的所有进程。
+func findPortHolders(proc config.Process) ([]portHolder, error) {
+	hostIPs, err := resolveHost(proc.Host)
+	if err != nil {
+		return nil, err
+	}
+	connections, err := psnet.Connections("tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int32]bool)
+	var holders []portHolder
+	for _, conn := range connections {
+		if conn.Status != "LISTEN" || int(conn.Laddr.Port) != proc.Port || !listenAddrMatches(conn.Laddr.IP, hostIPs) {
+			continue
+		}
+		if seen[conn.Pid] {
+			continue
+		}
+		seen[conn.Pid] = true
+		holders = append(holders, describePortHolder(conn.Pid))
+	}
+	return holders, nil
+}
+
+// describePortHolder 获取占用端口的进程的名称，以及它是否由当前 runtime_dir 的 procmate 启动。
+func describePortHolder(pid int32) portHolder {
+	h := portHolder{PID: pid}
+	if pid == 0 {
+		// 没有权限查看其他用户的进程时无法得知 PID
+		return h
+	}
+	p, err := gops.NewProcess(pid)
+	if err != nil {
+		return h
+	}
+	h.Name, _ = p.Name()
+
+	env, err := p.Environ()
+	if err != nil {
+		return h
+	}
+	var name, runtimeDir string
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, envManagedProcess+"="); ok {
+			name = v
+		} else if v, ok := strings.CutPrefix(kv, envRuntimeDir+"="); ok {
+			runtimeDir = v
+		}
+	}
+	if name != "" && runtimeDir == absRuntimeDir() {
+		h.Managed = name
+	}
+	return h
+}
+
+// liveManagedProcess 判断端口占用者是否属于某个正在运行的受管进程 (即其 PID 文件中的进程或其子孙进程)。
+// 是则返回该进程的名称，否则说明占用者是残留进程，返回空字符串。
+func liveManagedProcess(h portHolder) string {
+	for _, proc := range config.Current().Processes {
+		if proc.Name != h.Managed {
+			continue
+		}
+		pid, err := ReadPid(proc)
+		if err != nil {
+			return ""
+		}
+		if running, _ := IsRunning(proc); !running {
+			return ""
+		}
+		if int32(pid) == h.PID {
+			return proc.Name
+		}
+		children, err := childProcesses()
+		if err != nil {
+			// 无法确定时按正在运行处理，不停止它
+			return proc.Name
+		}
+		for _, child := range descendantPids(children, int32(pid)) {
+			if child == h.PID {
+				return proc.Name
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// stopTimeout 返回进程的停止超时时间。
+func stopTimeout(proc config.Process) time.Duration {
+	timeout := config.Current().Settings.DefaultStopTimeoutSec
+	if proc.StopTimeoutSec > 0 {
+		timeout = proc.StopTimeoutSec
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// terminatePid 向进程发送 SIGTERM，timeout 内未退出则强制终止。
+func terminatePid(pid int, timeout time.Duration) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		// Windows 不支持 SIGTERM，直接强制终止
+		p.Kill()
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		exists, err := gops.PidExists(int32(pid))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	fmt.Printf("⚠️ PID=%d 在 %v 内未退出，强制终止...\n", pid, timeout)
+	if err := p.Kill(); err != nil {
+		return fmt.Errorf("强制终止 PID=%d 失败: %w", pid, err)
+	}
+	return nil
+}
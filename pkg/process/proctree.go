@@ -0,0 +1,35 @@
+package process
+
+import (
+	gops "github.com/shirou/gopsutil/v3/process"
+)
+
+// childProcesses 返回系统中所有进程的父子关系 (父 PID -> 子 PID 列表)。
+func childProcesses() (map[int32][]int32, error) {
+	procs, err := gops.Processes()
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[int32][]int32)
+	for _, p := range procs {
+		if ppid, err := p.Ppid(); err == nil {
+			children[ppid] = append(children[ppid], p.Pid)
+		}
+	}
+	return children, nil
+}
+
+// descendantPids 返回 pid 的所有后代进程，不包括 pid 本身。
+func descendantPids(children map[int32][]int32, pid int32) []int32 {
+	var result []int32
+	queue := []int32{pid}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range children[current] {
+			result = append(result, child)
+			queue = append(queue, child)
+		}
+	}
+	return result
+}
@@ -0,0 +1,238 @@
+package process
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"procmate/pkg/config"
+)
+
+// logReadyState 记录基于日志的就绪检查的进度，保存在 <runtime_dir>/pids/<name>.ready。
+// 启动进程时记录各日志文件当前的末尾位置，之后的检查只扫描新写入的内容，
+// 日志在多次运行间追加写入，之前运行留下的就绪信号不会被误认为本次运行的。
+type logReadyState struct {
+	PID       int              `json:"pid"`
+	Ready     bool             `json:"ready"`
+	CheckedAt time.Time        `json:"checked_at"`        // 上次检查的时间，之后轮转出的备份需要补充扫描
+	Offsets   map[string]int64 `json:"offsets,omitempty"` // 各日志文件已扫描的字节数，总是位于行首
+}
+
+// newReadyState 返回一个新启动的进程的就绪检查进度，在启动进程前调用以记录各日志文件当前的末尾位置。
+func newReadyState(proc config.Process) *logReadyState {
+	state := &logReadyState{CheckedAt: time.Now(), Offsets: make(map[string]int64)}
+	logFiles, _ := GetManagedLogFiles(proc)
+	for _, logFile := range logFiles {
+		if fi, err := os.Stat(logFile); err == nil {
+			state.Offsets[logFile] = fi.Size()
+		}
+	}
+	return state
+}
+
+// readyStates 保存本进程内最近一次检查的进度 (K: 进程名)。
+// 只读的检查 (status、top、metrics 等) 不写入 .ready 文件，进度只保留在内存中。
+var (
+	readyStatesMu sync.Mutex
+	readyStates   = make(map[string]*logReadyState)
+)
+
+// loadReadyState 读取 pid 对应的就绪检查进度，本进程内存中有更新的进度时使用内存中的。
+// 记录不存在或属于之前的运行时 (如由旧版本 procmate 启动) 从当前日志文件的开头扫描。
+func loadReadyState(proc config.Process, pid int) *logReadyState {
+	state := &logReadyState{}
+	if path, err := getReadyStateFile(proc); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, state)
+		}
+	}
+
+	readyStatesMu.Lock()
+	if cached := readyStates[proc.Name]; cached != nil && cached.PID == pid &&
+		(state.PID != pid || cached.CheckedAt.After(state.CheckedAt)) {
+		state = cached.clone()
+	}
+	readyStatesMu.Unlock()
+
+	if state.PID != pid {
+		state = &logReadyState{PID: pid, CheckedAt: time.Now()}
+	}
+	if state.Offsets == nil {
+		state.Offsets = make(map[string]int64)
+	}
+	return state
+}
+
+// rememberReadyState 将检查进度保存在本进程的内存中。
+func rememberReadyState(proc config.Process, state *logReadyState) {
+	readyStatesMu.Lock()
+	readyStates[proc.Name] = state.clone()
+	readyStatesMu.Unlock()
+}
+
+// clone 返回进度的深拷贝。
+func (s *logReadyState) clone() *logReadyState {
+	c := *s
+	c.Offsets = make(map[string]int64, len(s.Offsets))
+	for k, v := range s.Offsets {
+		c.Offsets[k] = v
+	}
+	return &c
+}
+
+// saveReadyState 保存就绪检查进度。先写临时文件再重命名，并发读取的 procmate 不会读到不完整的内容。
+func saveReadyState(proc config.Process, state *logReadyState) error {
+	path, err := getReadyStateFile(proc)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// checkLogReady 从上次检查的位置继续扫描进程的日志，查找就绪信号。
+// persist 为 true 时将进度写入 .ready 文件，只有 watch 巡检和启动流程这样做，
+// 只读的检查只把进度保留在内存中，避免多个读取方同时改写同一个文件。
+func checkLogReady(proc config.Process, logFiles []string, persist bool) (bool, error) {
+	pid, err := ReadPid(proc)
+	if err != nil {
+		return false, fmt.Errorf("读取 PID 失败: %w", err)
+	}
+	state := loadReadyState(proc, pid)
+	if state.Ready {
+		return true, nil
+	}
+
+	loc := time.Local
+	if !effectiveLogOptions(proc).LocalTime {
+		loc = time.UTC
+	}
+
+	now := time.Now()
+	var checkErr error
+	for _, logFile := range logFiles {
+		found, offset, err := checkLog(logFile, state.Offsets[logFile], state.CheckedAt, loc)
+		state.Offsets[logFile] = offset
+		if err != nil {
+			checkErr = err
+		}
+		if found {
+			state.Ready = true
+			break
+		}
+	}
+	state.CheckedAt = now
+	rememberReadyState(proc, state)
+	if persist {
+		if err := saveReadyState(proc, state); err != nil && checkErr == nil {
+			checkErr = fmt.Errorf("保存就绪检查进度失败: %w", err)
+		}
+	}
+	return state.Ready, checkErr
+}
+
+// checkLog 从 offset 开始扫描日志文件中新写入的完整行，检查是否包含指定的关键字，并返回新的扫描位置。
+// since 之后日志发生了轮转时，先扫描轮转出的备份 (其中第一个从 offset 开始)，再从头扫描新的日志文件。
+func checkLog(logFile string, offset int64, since time.Time, backupLoc *time.Location) (bool, int64, error) {
+	// !!! 注意：这是一个硬编码值 !!!
+	readinessPattern := "started successfully"
+	match := func(line string) bool { return strings.Contains(line, readinessPattern) }
+
+	// 先于打开日志文件列出备份，避免漏掉两者之间发生的轮转
+	var rotated []logBackup
+	for _, b := range listLogBackups(logFile, backupLoc) {
+		// 备份文件名中的时间戳精确到毫秒
+		if !b.RotatedAt.Before(since.Truncate(time.Millisecond)) {
+			rotated = append(rotated, b)
+		}
+	}
+	// 某个备份读取失败时继续扫描其余日志，未找到关键字时再将其返回给调用方
+	var backupErr error
+	for _, b := range rotated {
+		found, _, err := scanLinesFrom(b.Path, offset, false, match)
+		if found {
+			return true, offset, nil
+		}
+		if err != nil && backupErr == nil {
+			backupErr = fmt.Errorf("扫描轮转的日志 %s 失败: %w", b.Path, err)
+		}
+		offset = 0
+	}
+
+	fi, err := os.Stat(logFile)
+	if err != nil {
+		return false, offset, fmt.Errorf("读取日志文件 %s 失败: %w", logFile, err)
+	}
+	if fi.Size() < offset {
+		// 日志被截断或以其他方式轮转，从头扫描
+		offset = 0
+	}
+
+	found, offset, err := scanLinesFrom(logFile, offset, true, match)
+	if err != nil {
+		return false, offset, fmt.Errorf("读取日志文件 %s 失败: %w", logFile, err)
+	}
+	if found {
+		return true, offset, nil // 找到了！
+	}
+	if backupErr != nil {
+		return false, offset, backupErr
+	}
+	return false, offset, fmt.Errorf("在 %s 中未找到关键字 '%s'", logFile, readinessPattern)
+}
+
+// scanLinesFrom 从 offset (解压后的位置) 开始逐行扫描文件 (支持 .gz)，直到 match 返回 true。
+// completeOnly 为 true 时不扫描末尾尚未写完的行，返回的位置停在该行行首，下次从这里继续。
+func scanLinesFrom(path string, offset int64, completeOnly bool, match func(line string) bool) (bool, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, offset, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return false, offset, fmt.Errorf("解压 %s 失败: %w", path, err)
+		}
+		defer gz.Close()
+		if _, err := io.CopyN(io.Discard, gz, offset); err != nil {
+			return false, offset, err
+		}
+		r = gz
+	} else if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return false, offset, err
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		complete := strings.HasSuffix(line, "\n")
+		if len(line) > 0 && (complete || !completeOnly) {
+			offset += int64(len(line))
+			if match(strings.TrimRight(line, "\r\n")) {
+				return true, offset, nil
+			}
+		}
+		if err == io.EOF {
+			return false, offset, nil
+		}
+		if err != nil {
+			return false, offset, err
+		}
+	}
+}
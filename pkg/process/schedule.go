@@ -0,0 +1,218 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"procmate/pkg/config"
+
+	"github.com/robfig/cron/v3"
+)
+
+// 定时任务上次运行尚未结束时的处理方式
+const (
+	OverlapSkip         = "skip"          // 跳过本次运行 (默认)
+	OverlapQueue        = "queue"         // 等上次运行结束后再运行，最多排队一次
+	OverlapKillPrevious = "kill-previous" // 停止上次运行，然后运行
+)
+
+// ParseSchedule 解析定时任务的 cron 表达式。
+func ParseSchedule(spec string) (cron.Schedule, error) {
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 schedule '%s': %w", spec, err)
+	}
+	return sched, nil
+}
+
+// NextRun 返回定时任务在 after 之后的下一次计划运行时间 (不含随机延迟)。
+func NextRun(proc config.Process, after time.Time) (time.Time, error) {
+	sched, err := ParseSchedule(proc.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return sched.Next(after), nil
+}
+
+// overlapPolicy 返回定时任务的重叠处理方式，未配置时按 skip 处理 (无效的配置在加载时已被拒绝)。
+func overlapPolicy(proc config.Process) string {
+	switch proc.Overlap {
+	case OverlapQueue, OverlapKillPrevious:
+		return proc.Overlap
+	default:
+		return OverlapSkip
+	}
+}
+
+// RunJob 立即运行一次定时任务：启动进程后不等待就绪，也不等待其结束。
+// 复用常驻进程的日志、环境变量和运行身份等配置。上次运行尚未结束时返回错误。
+func RunJob(proc config.Process) error {
+	lock, err := lockProcess(proc)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if running, _ := IsRunning(proc); running {
+		pid, _ := ReadPid(proc)
+		return fmt.Errorf("定时任务 '%s' 的上次运行 (PID %d) 尚未结束", proc.Name, pid)
+	}
+
+	// 启动前先预留本次运行的记录 (PID 为 0)，运行很快结束时回收协程也能匹配到本次运行。
+	// 启动过程较慢 (端口检查、cgroup、日志进程等)，期间不持有 statsMu，以免阻塞状态查询和退出记录
+	started := time.Now()
+	var previous *JobRun
+	statsMu.Lock()
+	updateHistory(proc.Name, func(h *ProcessHistory) {
+		previous = h.LastRun
+		h.LastRun = &JobRun{Started: started}
+	})
+	statsMu.Unlock()
+
+	pid, err := spawn(proc, StartOptions{})
+
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	updateHistory(proc.Name, func(h *ProcessHistory) {
+		if h.LastRun == nil || !h.LastRun.Started.Equal(started) {
+			return
+		}
+		switch {
+		case err != nil:
+			h.LastRun = previous
+		case h.LastRun.PID != pid:
+			// 回收协程匹配到的不是本次运行的退出
+			h.LastRun.PID = pid
+			h.LastRun.Exit = nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("⏰ 定时任务 '%s' 已开始运行 (PID: %d)\n", proc.Name, pid)
+	return nil
+}
+
+// recordSkippedRun 记录一次因上次运行尚未结束而跳过的运行。
+func recordSkippedRun(proc config.Process, reason string) {
+	fmt.Printf("⏭️ 跳过定时任务 '%s' 的本次运行: %s\n", proc.Name, reason)
+	statsMu.Lock()
+	updateHistory(proc.Name, func(h *ProcessHistory) { h.SkippedRuns++ })
+	statsMu.Unlock()
+	RecordEvent(Event{Process: proc.Name, Type: EventSkip, Message: reason})
+}
+
+// scheduleEntry 是调度器中一个定时任务的状态。
+type scheduleEntry struct {
+	spec    string
+	sched   cron.Schedule // schedule 无效时为 nil
+	next    time.Time
+	pending atomic.Bool // 有一次运行正在随机延迟或排队等待
+}
+
+// StartScheduler 按 schedule 运行 procs 返回的定时任务，直到 ctx 结束。由 watch 守护进程调用。
+// 每次检查时重新读取进程列表，重新加载配置后新的计划立即生效。
+func StartScheduler(ctx context.Context, procs func() []config.Process) {
+	go func() {
+		entries := make(map[string]*scheduleEntry)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			now := time.Now()
+			seen := make(map[string]bool)
+			for _, proc := range procs() {
+				if proc.Schedule == "" {
+					continue
+				}
+				seen[proc.Name] = true
+
+				e := entries[proc.Name]
+				if e == nil || e.spec != proc.Schedule {
+					e = &scheduleEntry{spec: proc.Schedule}
+					sched, err := ParseSchedule(proc.Schedule)
+					if err != nil {
+						fmt.Printf("❌ 定时任务 '%s': %v\n", proc.Name, err)
+					} else {
+						e.sched = sched
+						e.next = sched.Next(now)
+					}
+					entries[proc.Name] = e
+				}
+				if e.sched == nil || now.Before(e.next) {
+					continue
+				}
+				e.next = e.sched.Next(now)
+
+				if !e.pending.CompareAndSwap(false, true) {
+					recordSkippedRun(proc, "上一次触发仍在等待运行")
+					continue
+				}
+				go func(proc config.Process, e *scheduleEntry) {
+					defer e.pending.Store(false)
+					runScheduled(ctx, proc)
+				}(proc, e)
+			}
+			for name := range entries {
+				if !seen[name] {
+					delete(entries, name)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// runScheduled 按计划运行一次定时任务：先随机延迟，再按 overlap 处理尚未结束的上次运行。
+func runScheduled(ctx context.Context, proc config.Process) {
+	if proc.JitterSec > 0 {
+		delay := time.Duration(rand.Int63n(int64(proc.JitterSec) * int64(time.Second)))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+
+	if running, _ := IsRunning(proc); running {
+		switch overlapPolicy(proc) {
+		case OverlapQueue:
+			fmt.Printf("⏳ 定时任务 '%s' 的上次运行尚未结束，等待其结束后运行...\n", proc.Name)
+			for running {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+				running, _ = IsRunning(proc)
+			}
+		case OverlapKillPrevious:
+			fmt.Printf("🔪 定时任务 '%s' 的上次运行尚未结束，正在停止...\n", proc.Name)
+			if err := Stop(proc); err != nil {
+				fmt.Printf("❌ 停止定时任务 '%s' 的上次运行失败: %v\n", proc.Name, err)
+				return
+			}
+		default:
+			recordSkippedRun(proc, "上次运行尚未结束")
+			return
+		}
+	}
+
+	// 与 start 等操作一样持有共享的全局锁，避免与 scale 等操作同时进行
+	lock, err := LockGlobal(false)
+	if err != nil {
+		fmt.Printf("❌ 运行定时任务 '%s' 失败: %v\n", proc.Name, err)
+		return
+	}
+	defer lock.Unlock()
+	if err := RunJob(proc); err != nil {
+		fmt.Printf("❌ 运行定时任务 '%s' 失败: %v\n", proc.Name, err)
+	}
+}
@@ -2,95 +2,187 @@ package process
 
 import (
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"time"
 
 	"procmate/pkg/config"
-
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // Start 启动指定进程，并根据配置处理其日志输出。
-// - 如果配置了 log_file，将使用 lumberjack 进行日志轮转。
-// - 否则，日志将被丢弃。
+// - stdout/stderr 默认合并写入同一个日志文件，使用 lumberjack 进行日志轮转。
+// - 配置了 stderr_log 或 merge_stderr: false 时，stderr 单独记录。
+// - 配置了 disable_log 时，日志将被丢弃。
 // - 写入 PID 文件。
 // - 启动后会阻塞，直到进程“就绪”或超时。
+// - 启动期间持有进程锁，避免多个 procmate 同时启动同一进程。
 func Start(proc config.Process) error {
+	return StartWithOptions(proc, StartOptions{})
+}
+
+// StartOptions 是单次启动的可选行为，由发起启动的命令决定，不影响其他启动。
+type StartOptions struct {
+	ForceFreePort bool // 端口被之前由 procmate 启动的残留进程占用时，先停止该进程
+}
+
+// StartWithOptions 与 Start 相同，但按 opts 调整启动行为。
+func StartWithOptions(proc config.Process, opts StartOptions) error {
+	lock, err := lockProcess(proc)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	return start(proc, opts)
+}
+
+// start 是 Start 的实际实现，调用方需持有进程锁。
+func start(proc config.Process, opts StartOptions) error {
 	// 检查进程是否已在运行
 	isRunning, _ := IsRunning(proc)
 	if isRunning {
 		// 如果已经在运行，我们还需要检查它是否就绪
-		isReady, _ := IsReady(proc)
+		isReady, _ := CheckReady(proc)
 		if isReady {
 			fmt.Printf("🟡 进程 '%s' 已在运行并就绪。\n", proc.Name)
 			return nil
 		}
 		fmt.Printf("🟠 进程 '%s' 已在运行但尚未就绪，将继续等待...\n", proc.Name)
 	} else {
-		// === 构造命令 ===
-		cmd := exec.Command("bash", "-c", proc.Command)
-		cmd.Dir = proc.WorkDir
-
-		// 应用环境变量（继承系统环境 + 进程配置）
-		if len(proc.Environment) > 0 {
-			cmd.Env = os.Environ()
-			for key, val := range proc.Environment {
-				cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, val))
-			}
-		}
-
-		// === 配置日志 ===
-		var logWriter io.Writer = io.Discard
-		logFilePath, err := GetLogFile(proc)
+		pid, err := spawn(proc, opts)
 		if err != nil {
-			return fmt.Errorf("获取日志文件路径失败: %w", err)
-		}
-		logOptions := config.Cfg.Settings.LogOptions
-		logWriter = &lumberjack.Logger{
-			Filename:   logFilePath,
-			MaxSize:    logOptions.MaxSizeMB,
-			MaxBackups: logOptions.MaxBackups,
-			MaxAge:     logOptions.MaxAgeDays,
-			Compress:   logOptions.Compress,
-			LocalTime:  logOptions.LocalTime,
-		}
-		cmd.Stdout = logWriter
-		cmd.Stderr = logWriter
-
-		// === 启动进程 ===
-		if err := cmd.Start(); err != nil {
-			return fmt.Errorf("启动命令 '%s' 失败: %w", proc.Name, err)
-		}
-
-		// === 保留pid并持久化到文件 ===
-		pid := cmd.Process.Pid
-		if err := WritePid(proc, pid); err != nil {
-			cmd.Process.Kill()
-			return fmt.Errorf("为进程 '%s' 写入 PID 文件失败: %w", proc.Name, err)
+			return err
 		}
 		fmt.Printf("... 进程 %s 已启动 (PID: %d)，正在等待其就绪...\n", proc.Name, pid)
 	}
 
 	// === 等待进程就绪 ===
+	waitStart := time.Now()
 	if err := waitForReady(proc); err != nil {
+		RecordEvent(Event{Process: proc.Name, Type: EventProbeFailed, Message: err.Error()})
 		// 停止失败的进程
-		if stopErr := Stop(proc); stopErr != nil {
+		if stopErr := stop(proc); stopErr != nil {
 			fmt.Printf("⚠️ 停止超时的进程 '%s' 失败: %v。可能需要手动清理。\n", proc.Name, stopErr)
 		}
 		return err
 	}
+	pid, _ := ReadPid(proc)
+	RecordEvent(Event{Process: proc.Name, Type: EventReady, PID: pid,
+		Message: fmt.Sprintf("等待 %v 后就绪", time.Since(waitStart).Round(time.Millisecond))})
 
 	return nil
 }
 
+// spawn 启动进程并写入 PID 文件，不等待其就绪。
+// 在后台回收子进程并记录其退出状态。调用方需持有进程锁。
+func spawn(proc config.Process, opts StartOptions) (int, error) {
+	// === 检查端口是否被其他进程占用 ===
+	if err := checkPortConflict(proc, opts.ForceFreePort); err != nil {
+		return 0, fmt.Errorf("无法启动进程 '%s': %w", proc.Name, err)
+	}
+
+	// === 解析运行身份 ===
+	cred, err := resolveCredential(proc)
+	if err != nil {
+		return 0, fmt.Errorf("解析进程 '%s' 的运行身份失败: %w", proc.Name, err)
+	}
+
+	// === 构造命令 ===
+	cmd, err := buildCommand(proc, cred)
+	if err != nil {
+		return 0, fmt.Errorf("构造进程 '%s' 的命令失败: %w", proc.Name, err)
+	}
+	cmd.Dir = proc.WorkDir
+
+	// 应用环境变量（继承系统环境 + procmate 标记 + 进程配置）
+	cmd.Env = append(os.Environ(), managedEnv(proc)...)
+	if cred != nil && cred.Username != "" {
+		// 切换用户时同步更新身份相关的环境变量，可被进程配置覆盖
+		cmd.Env = append(cmd.Env,
+			"USER="+cred.Username,
+			"LOGNAME="+cred.Username,
+			"HOME="+cred.HomeDir,
+		)
+	}
+	for key, val := range proc.Environment {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, val))
+	}
+
+	// === 配置日志 ===
+	// 优先通过独立的日志中转进程写日志，procmate 退出后子进程的输出仍会被记录
+	var pipes *logPipes
+	if !proc.DisableLog && useLogShim {
+		pipes, err = openLogPipes(proc)
+		if err != nil {
+			return 0, fmt.Errorf("为进程 '%s' 配置日志失败: %w", proc.Name, err)
+		}
+		defer pipes.Close()
+		cmd.Stdout = pipes.Stdout
+		cmd.Stderr = pipes.Stderr
+	} else {
+		stdoutWriter, stderrWriter, err := openLogWriters(proc)
+		if err != nil {
+			return 0, fmt.Errorf("获取日志文件路径失败: %w", err)
+		}
+		cmd.Stdout = stdoutWriter
+		cmd.Stderr = stderrWriter
+	}
+
+	// === 应用运行身份 ===
+	if err := applyCredential(cmd, cred, proc); err != nil {
+		return 0, fmt.Errorf("为进程 '%s' 设置运行身份失败: %w", proc.Name, err)
+	}
+
+	// === 配置 cgroup ===
+	closeCgroup, err := setupCgroup(cmd, proc)
+	if err != nil {
+		return 0, fmt.Errorf("为进程 '%s' 配置 cgroup 失败: %w", proc.Name, err)
+	}
+
+	// === 启动进程 ===
+	// 记录启动前日志的末尾位置，就绪检查只扫描之后写入的内容
+	readyState := newReadyState(proc)
+	err = cmd.Start()
+	closeCgroup()
+	if err != nil {
+		return 0, fmt.Errorf("启动命令 '%s' 失败: %w", proc.Name, err)
+	}
+
+	// === 启动日志中转进程 ===
+	if pipes != nil {
+		if err := pipes.startShim(); err != nil {
+			killProcessGroup(cmd.Process)
+			return 0, fmt.Errorf("为进程 '%s' 启动日志中转失败: %w", proc.Name, err)
+		}
+	}
+
+	// === 保留pid并持久化到文件 ===
+	pid := cmd.Process.Pid
+	if err := WritePid(proc, pid); err != nil {
+		killProcessGroup(cmd.Process)
+		return 0, fmt.Errorf("为进程 '%s' 写入 PID 文件失败: %w", proc.Name, err)
+	}
+	readyState.PID = pid
+	if err := saveReadyState(proc, readyState); err != nil {
+		fmt.Printf("⚠️ 保存进程 '%s' 的就绪检查进度失败: %v\n", proc.Name, err)
+	}
+	RecordEvent(Event{Process: proc.Name, Type: EventSpawn, PID: pid})
+
+	// 在后台回收子进程，procmate 长时间运行 (watch / run) 时不会留下僵尸进程，并记录其退出码
+	go func() {
+		cmd.Wait()
+		if cmd.ProcessState != nil {
+			recordExit(proc.Name, cmd.ProcessState)
+		}
+	}()
+
+	return pid, nil
+}
+
 // waitForReady 会在指定超时时间内等待进程就绪。
 // - 就绪则返回 nil
 // - 超时则返回 error
 func waitForReady(proc config.Process) error {
 	// 超时时间：优先用进程自身配置，否则用全局配置
-	timeout := time.Duration(config.Cfg.Settings.DefaultStartTimeoutSec) * time.Second
+	timeout := time.Duration(config.Current().Settings.DefaultStartTimeoutSec) * time.Second
 	if proc.StartTimeoutSec > 0 {
 		timeout = time.Duration(proc.StartTimeoutSec) * time.Second
 	}
@@ -100,7 +192,7 @@ func waitForReady(proc config.Process) error {
 
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
-		ready, _ := IsReady(proc)
+		ready, _ := CheckReady(proc)
 		if ready {
 			return nil // 成功！
 		}
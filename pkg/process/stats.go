@@ -0,0 +1,80 @@
+package process
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// ProcessStats 记录当前 procmate 进程 (通常是 watch 守护进程) 运行期间观察到的进程事件。
+// 这些信息保存在内存中，供 metrics 等功能使用；重启次数和退出记录同时持久化到进程历史中。
+type ProcessStats struct {
+	Restarts          int           // 被 watch 自动重启的次数
+	HasExited         bool          // 是否观察到过进程退出
+	LastExitCode      int           // 最近一次退出码，被信号终止时为 -1
+	LastExitTime      time.Time     // 最近一次退出的时间
+	LastStartDuration time.Duration // 最近一次从启动到就绪的耗时，0 表示未知
+}
+
+var (
+	statsMu sync.Mutex
+	stats   = make(map[string]*ProcessStats)
+)
+
+// statsFor 返回进程的统计信息，调用方需持有 statsMu。
+func statsFor(name string) *ProcessStats {
+	s, ok := stats[name]
+	if !ok {
+		s = &ProcessStats{}
+		stats[name] = s
+	}
+	return s
+}
+
+// GetProcessStats 返回进程统计信息的副本。
+func GetProcessStats(name string) ProcessStats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	return *statsFor(name)
+}
+
+// RecordRestart 记录一次自动重启。
+func RecordRestart(name string) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	statsFor(name).Restarts++
+	updateHistory(name, func(h *ProcessHistory) { h.Restarts++ })
+	RecordEvent(Event{Process: name, Type: EventRestart})
+}
+
+// recordStartDuration 记录进程从启动到就绪的耗时。
+func recordStartDuration(name string, d time.Duration) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	statsFor(name).LastStartDuration = d
+}
+
+// recordExit 记录进程的退出状态，由回收子进程的协程调用。
+func recordExit(name string, state *os.ProcessState) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	record := exitRecordOf(state)
+	s := statsFor(name)
+	s.HasExited = true
+	s.LastExitCode = record.Code
+	s.LastExitTime = record.Time
+	updateHistory(name, func(h *ProcessHistory) {
+		h.Exits = append([]ExitRecord{record}, h.Exits...)
+		if h.LastRun == nil {
+			return
+		}
+		// PID 为 0 表示 RunJob 已预留记录、尚未得知 PID，此时退出的只可能是本次运行
+		if h.LastRun.PID == 0 && h.LastRun.Exit == nil {
+			h.LastRun.PID = state.Pid()
+		}
+		if h.LastRun.PID == state.Pid() {
+			h.LastRun.Exit = &record
+		}
+	})
+	RecordEvent(Event{Time: record.Time, Process: name, Type: EventExit, PID: state.Pid(), Code: &record.Code, Signal: record.Signal})
+}
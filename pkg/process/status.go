@@ -1,10 +1,10 @@
 package process
 
 import (
+	"errors"
 	"fmt"
-	"net"
 	"os"
-	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -17,14 +17,23 @@ import (
 
 // ProcessInfo 包含了一个进程在运行时的所有动态信息。
 type ProcessInfo struct {
-	Name           string
-	IsRunning      bool
-	IsReady        bool
-	PID            int
-	Uptime         time.Duration
-	CPUPercent     float64
-	MemoryRSS      float64 // 单位: MB
-	ListeningPorts []string
+	Name           string        `json:"name"`
+	IsRunning      bool          `json:"running"`
+	IsReady        bool          `json:"ready"`
+	PID            int           `json:"pid"`
+	Uptime         time.Duration `json:"uptime_ns"`
+	CPUPercent     float64       `json:"cpu_percent"`          // 最近一个采样间隔内的 CPU 使用率
+	CPUAvg1m       *float64      `json:"cpu_avg_1m,omitempty"` // 最近 1 分钟的平均 CPU 使用率，仅 watch 守护进程提供
+	CPUAvg5m       *float64      `json:"cpu_avg_5m,omitempty"` // 最近 5 分钟的平均 CPU 使用率，仅 watch 守护进程提供
+	MemoryRSS      float64       `json:"memory_rss_mb"`        // 单位: MB
+	ListeningPorts []string      `json:"listening_ports"`
+	Cgroup         *CgroupUsage  `json:"cgroup,omitempty"`    // cgroup 资源使用情况，未配置 cgroup 限制时为 nil
+	TimedOut       bool          `json:"timed_out,omitempty"` // 未能在 status_timeout_ms 内获取到信息，此时其它字段无意义
+	Schedule       string        `json:"schedule,omitempty"`  // 定时任务的 cron 表达式，常驻进程为空
+	LastRun        *JobRun       `json:"last_run,omitempty"`  // 定时任务的上次运行
+	NextRun        *time.Time    `json:"next_run,omitempty"`  // 定时任务的下次计划运行时间 (不含随机延迟)
+	SkippedRuns    int           `json:"skipped_runs,omitempty"`
+	Supervision    string        `json:"supervision,omitempty"` // 守护状态 (stopped / unmanaged)，正常守护时为空
 }
 
 // IsRunning 运行中探针。
@@ -55,62 +64,176 @@ func IsRunning(proc config.Process) (bool, error) {
 
 // IsReady 准备就绪探针。
 // 通过读取 PID 文件获取 PID，然后向该进程发送 Signal 0 验证其存在性。
+// 未就绪时返回的错误说明原因 (如日志中尚未出现就绪信号)，调用方可以忽略。
+// 只读：基于日志的检查进度不会写入 runtime_dir，供 status、top、metrics 等使用。
 func IsReady(proc config.Process) (bool, error) {
+	return probeReady(proc, false)
+}
+
+// CheckReady 与 IsReady 相同，但会保存基于日志的就绪检查进度，只应由 watch 巡检和启动流程调用。
+func CheckReady(proc config.Process) (bool, error) {
+	return probeReady(proc, true)
+}
+
+// probeReady 是 IsReady 和 CheckReady 的实现，persist 决定是否保存检查进度。
+func probeReady(proc config.Process, persist bool) (bool, error) {
 	var isReady bool
 	var checkErr error
 	// --- 4. 根据 Port 字段动态选择检查策略 ---
 	if proc.Port > 0 {
 		// 主策略：检查端口
-		isReady, checkErr = checkPort(proc.Port)
+		isReady, checkErr = checkPort(proc)
 		if isReady {
 			return true, nil
 		}
 	} else {
-		// 备用策略：扫描日志
-		logFile, err := GetLogFile(proc)
+		// 备用策略：扫描日志 (stdout 以及单独记录时的 stderr)，只扫描本次启动后新写入的内容
+		logFiles, err := GetManagedLogFiles(proc)
 		if err != nil {
 			return false, fmt.Errorf("获取日志文件路径失败: %w", err)
 		}
 
-		isReady, checkErr = checkLog(logFile)
+		// 关闭日志时没有可用的就绪信号，运行即视为就绪
+		if len(logFiles) == 0 {
+			return IsRunning(proc)
+		}
+
+		isReady, checkErr = checkLogReady(proc, logFiles, persist)
 		if isReady {
-			fmt.Printf("成功: 进程 '%s' 的日志中发现就绪信号。\n", proc.Name)
 			return true, nil
 		}
 	}
 
-	// 打印每次检查失败的原因，便于调试
-	if checkErr != nil {
-		// 为了避免日志刷屏，可以只在调试模式下打印
-		fmt.Printf("调试: '%s' 的就绪检查失败: %v\n", proc.Name, checkErr)
-	}
+	// 未就绪的原因交给调用方，不在这里打印：status、metrics、top 会并发检查所有进程
+	return false, checkErr
+}
+
+// statusWorkers 是并发获取进程信息的协程数量上限
+const statusWorkers = 8
+
+// defaultStatusTimeout 是获取单个进程信息的默认超时时间
+const defaultStatusTimeout = 3 * time.Second
 
-	return false, nil
+// statusTimeout 返回获取单个进程信息的超时时间。
+func statusTimeout() time.Duration {
+	if ms := config.Current().Settings.StatusTimeoutMs; ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return defaultStatusTimeout
 }
 
-// checkLog 检查日志文件中是否包含指定的关键字
-func checkLog(logFile string) (bool, error) {
-	// !!! 注意：这是一个硬编码值 !!!
-	readinessPattern := "started successfully"
+// GetProcessInfos 并发获取一组进程的运行时信息，结果与 procs 一一对应。
+//   - 单个进程超过 status_timeout_ms 仍未返回时，其结果标记为 TimedOut，不会阻塞其它进程。
+//   - CPU 使用率为一个采样间隔内的当前值，所有进程共用一次采样。
+//   - 在 watch 守护进程中还会附带 CPU 使用率的 1 分钟 / 5 分钟平均值。
+func GetProcessInfos(procs []config.Process) ([]ProcessInfo, error) {
+	infos := make([]ProcessInfo, len(procs))
+	errs := make([]error, len(procs))
+	timeout := statusTimeout()
 
-	content, err := os.ReadFile(logFile)
-	if err != nil {
-		return false, fmt.Errorf("读取日志文件 %s 失败: %w", logFile, err)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(statusWorkers, len(procs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				infos[i], errs[i] = getProcessInfoWithTimeout(procs[i], timeout)
+			}
+		}()
 	}
-	if strings.Contains(string(content), readinessPattern) {
-		return true, nil // 找到了！
+	for i := range procs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for _, info := range infos {
+		if info.IsRunning {
+			pids = append(pids, info.PID)
+		}
+	}
+	usage := sampleCPU(pids, cpuSampleInterval())
+	for i := range infos {
+		info := &infos[i]
+		if !info.IsRunning {
+			continue
+		}
+		info.CPUPercent = usage[info.PID]
+		if avg1m, avg5m, ok := cpuAverages(info.Name, info.PID); ok {
+			info.CPUAvg1m = &avg1m
+			info.CPUAvg5m = &avg5m
+		}
+	}
+	return infos, nil
+}
+
+// getProcessInfoWithTimeout 获取进程信息，超时后放弃等待 (后台的查询会自行结束)。
+func getProcessInfoWithTimeout(proc config.Process, timeout time.Duration) (ProcessInfo, error) {
+	type result struct {
+		info *ProcessInfo
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		info, err := getProcessInfo(proc)
+		done <- result{info, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return ProcessInfo{}, fmt.Errorf("获取进程 '%s' 信息失败: %w", proc.Name, r.err)
+		}
+		return *r.info, nil
+	case <-timer.C:
+		return ProcessInfo{Name: proc.Name, TimedOut: true}, nil
 	}
-	return false, fmt.Errorf("在 %s 中未找到关键字 '%s'", logFile, readinessPattern)
 }
 
 // GetProcessInfo 通过PID文件和系统调用，获取一个进程的详细运行时信息。
 // 这是获取进程状态的核心功能，将系统交互的逻辑与命令行展示的逻辑彻底分离。
+// 获取多个进程的信息时应使用 GetProcessInfos，所有进程共用一次 CPU 采样。
 func GetProcessInfo(proc config.Process) (*ProcessInfo, error) {
+	infos, err := GetProcessInfos([]config.Process{proc})
+	if err != nil {
+		return nil, err
+	}
+	return &infos[0], nil
+}
+
+// fillScheduleInfo 填充定时任务的计划和上次运行信息。
+func fillScheduleInfo(info *ProcessInfo, proc config.Process) {
+	info.Schedule = proc.Schedule
+	if next, err := NextRun(proc, time.Now()); err == nil {
+		info.NextRun = &next
+	}
+	if h, err := GetProcessHistory(proc.Name); err == nil {
+		info.LastRun = h.LastRun
+		info.SkippedRuns = h.SkippedRuns
+	}
+}
+
+// getProcessInfo 获取进程除 CPU 使用率以外的运行时信息。
+func getProcessInfo(proc config.Process) (*ProcessInfo, error) {
 	// 初始化返回结构体，默认进程为离线状态
 	info := &ProcessInfo{
 		Name:      proc.Name,
 		IsRunning: false,
 	}
+	if proc.Schedule != "" {
+		fillScheduleInfo(info, proc)
+	}
+	if s, err := GetSupervision(proc.Name); err == nil && s != nil {
+		info.Supervision = s.State
+	}
 	// 1. 从 PID 文件中读取 PID
 	pid, err := ReadPid(proc)
 	if err != nil || pid == 0 {
@@ -129,8 +252,11 @@ func GetProcessInfo(proc config.Process) (*ProcessInfo, error) {
 	// --- 如果代码能执行到这里，说明进程确认在线 ---
 	info.IsRunning = true
 
-	isReady, _ := IsReady(proc)
-	info.IsReady = isReady
+	// 定时任务没有就绪状态
+	if proc.Schedule == "" {
+		isReady, _ := IsReady(proc)
+		info.IsReady = isReady
+	}
 
 	info.PID = pid
 
@@ -140,16 +266,16 @@ func GetProcessInfo(proc config.Process) (*ProcessInfo, error) {
 		info.Uptime = time.Since(time.UnixMilli(createTime)).Round(time.Second)
 	}
 
-	// 获取 CPU 使用率
-	if cpuPercent, err := p.CPUPercent(); err == nil {
-		info.CPUPercent = cpuPercent
-	}
-
 	// 获取内存使用情况 (RSS, 物理内存)
 	if memInfo, err := p.MemoryInfo(); err == nil {
 		info.MemoryRSS = float64(memInfo.RSS) / 1024 / 1024 // 字节转换为 MB
 	}
 
+	// 获取 cgroup 资源使用情况 (如果配置了 cgroup 限制)
+	if usage, err := GetCgroupUsage(proc); err == nil {
+		info.Cgroup = usage
+	}
+
 	// 获取网络连接，并筛选出正在监听的 TCP 端口
 	connections, err := psnet.ConnectionsPid("tcp", int32(pid))
 	if err != nil {
@@ -168,30 +294,3 @@ func GetProcessInfo(proc config.Process) (*ProcessInfo, error) {
 
 	return info, nil
 }
-
-// checkPort 检查指定 TCP 端口是否被占用。
-// 返回 true 表示端口已被占用，false 表示端口空闲。
-func checkPort(port int) (bool, error) {
-	// --- 增加 0 的判断 ---
-	if port == 0 {
-		// 0 被视为跳过检查
-		return true, nil
-	}
-
-	// --- 增加对无效端口的判断 ---
-	if port < 0 || port > 65535 {
-		// 无效端口被视为空闲
-		return false, fmt.Errorf("端口无效 %d", port)
-	}
-
-	// 尝试在本地建立监听
-	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
-	if err != nil {
-		// Listen 返回错误通常意味着端口已被占用 (EADDRINUSE)
-		return true, nil
-	}
-
-	// 监听成功，说明端口空闲，立即关闭监听器
-	listener.Close()
-	return false, nil
-}
@@ -11,8 +11,18 @@ import (
 
 var ErrPidfileNotFound = errors.New("pidfile not found")
 
-// Stop 负责停止一个指定的进程。
+// Stop 负责停止一个指定的进程，停止期间持有进程锁。
 func Stop(proc config.Process) error {
+	lock, err := lockProcess(proc)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	return stop(proc)
+}
+
+// stop 是 Stop 的实际实现，调用方需持有进程锁。
+func stop(proc config.Process) error {
 	// ===> 读取pid <===
 	pid, err := ReadPid(proc)
 	if err != nil {
@@ -29,31 +39,35 @@ func Stop(proc config.Process) error {
 		return fmt.Errorf("查找 PID=%d 的进程失败: %w", pid, err)
 	}
 
-	fmt.Printf("⏳ 向 PID=%d 发送 SIGTERM，请求进程 '%s' 优雅退出...\n", pid, proc.Name)
-	if err := process.Signal(syscall.SIGTERM); err != nil {
+	// 进程运行在独立的进程组中，信号发送给整个进程组，shell 派生的子进程也一并停止
+	fmt.Printf("⏳ 向 PID=%d 的进程组发送 SIGTERM，请求进程 '%s' 优雅退出...\n", pid, proc.Name)
+	RecordEvent(Event{Process: proc.Name, Type: EventStop, PID: pid})
+	if err := signalProcessGroup(process, syscall.SIGTERM); err != nil {
 		fmt.Printf("发送 SIGTERM 失败: %v，可能进程已退出。\n", err)
 	}
 
 	// ===> 应用可配置的停止超时 <===
-	timeout := config.Cfg.Settings.DefaultStopTimeoutSec
+	timeout := config.Current().Settings.DefaultStopTimeoutSec
 	if proc.StopTimeoutSec > 0 {
 		timeout = proc.StopTimeoutSec
 	}
 
 	stopped := false
 	for i := 0; i < timeout; i++ {
-		if err := process.Signal(syscall.Signal(0)); err != nil {
+		if err := signalProcessGroup(process, syscall.Signal(0)); err != nil {
 			stopped = true
 			break
 		}
 		time.Sleep(time.Second)
 	}
 
-	// 如果进程仍然存在，发送 SIGKILL 强制终止
+	// 如果进程组中仍有进程存在，发送 SIGKILL 强制终止
 	if !stopped {
 		fmt.Printf("⚠️ 进程 '%s' (PID=%d) 在 %d 秒内未退出，发送 SIGKILL...\n",
 			proc.Name, pid, timeout)
-		if err := process.Signal(syscall.SIGKILL); err != nil {
+		RecordEvent(Event{Process: proc.Name, Type: EventKill, PID: pid,
+			Message: fmt.Sprintf("%d 秒内未退出", timeout)})
+		if err := signalProcessGroup(process, syscall.SIGKILL); err != nil && !errors.Is(err, os.ErrProcessDone) && !errors.Is(err, syscall.ESRCH) {
 			return fmt.Errorf("发送 SIGKILL 失败: %w", err)
 		}
 	}
@@ -63,5 +77,35 @@ func Stop(proc config.Process) error {
 		return fmt.Errorf("清理 PID 文件失败: %w", err)
 	}
 
+	// 清理进程的 cgroup (如果有)
+	removeCgroup(proc)
+
+	return nil
+}
+
+// Kill 立即向进程发送 SIGKILL 并清理 PID 文件，用于停止的总时限已到时。
+// 不获取进程锁：此时持有锁的通常正是仍在等待该进程退出的 Stop。
+func Kill(proc config.Process, reason string) error {
+	pid, err := ReadPid(proc)
+	if err != nil {
+		if errors.Is(err, ErrPidfileNotFound) {
+			return nil
+		}
+		return err
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("查找 PID=%d 的进程失败: %w", pid, err)
+	}
+
+	fmt.Printf("⚡ 向 PID=%d 的进程组发送 SIGKILL，强制终止进程 '%s'...\n", pid, proc.Name)
+	RecordEvent(Event{Process: proc.Name, Type: EventKill, PID: pid, Message: reason})
+	if err := signalProcessGroup(process, syscall.SIGKILL); err != nil && !errors.Is(err, os.ErrProcessDone) && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("发送 SIGKILL 失败: %w", err)
+	}
+	if err := RemovePid(proc); err != nil {
+		return fmt.Errorf("清理 PID 文件失败: %w", err)
+	}
+	removeCgroup(proc)
 	return nil
 }
@@ -0,0 +1,141 @@
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 进程的守护状态。未设置时进程由 watch 正常守护。
+const (
+	SupervisionStopped   = "stopped"   // 被运维人员通过 stop 停止，watch 不会自动拉起，直到再次 start
+	SupervisionUnmanaged = "unmanaged" // 通过 unmanage 暂停守护，watch 不会拉起或停止它，直到 manage
+)
+
+// Supervision 是持久化在 <runtime_dir>/supervision/ 中的守护状态。
+// 守护状态保存在文件中，watch 重启后依然有效，其他 procmate 命令也可以直接修改。
+type Supervision struct {
+	State string    `json:"state"`
+	Since time.Time `json:"since"`
+}
+
+// watchPausedFile 是 watch --pause 写入的文件名，进程的状态文件均以 .json 结尾，不会冲突
+const watchPausedFile = "watch.paused"
+
+// getSupervisionDir 返回守护状态目录并确保其存在。
+// 格式：<runtime_dir>/supervision
+func getSupervisionDir() (string, error) {
+	runtimeDir, err := ensureCommonRuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(runtimeDir, "supervision")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create supervision directory '%s': %w", dir, err)
+	}
+	return dir, nil
+}
+
+// readSupervision 读取守护状态文件，文件不存在时返回 nil。
+func readSupervision(name string) (*Supervision, error) {
+	dir, err := getSupervisionDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Supervision
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("解析守护状态文件 '%s' 失败: %w", name, err)
+	}
+	return &s, nil
+}
+
+// writeSupervision 写入守护状态文件，s 为 nil 时删除该文件。
+func writeSupervision(name string, s *Supervision) error {
+	dir, err := getSupervisionDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, name)
+	if s == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// GetSupervision 返回进程的守护状态，未设置时返回 nil。
+func GetSupervision(name string) (*Supervision, error) {
+	return readSupervision(name + ".json")
+}
+
+// SetSupervision 设置进程的守护状态，state 为空时恢复正常守护。
+func SetSupervision(name, state string) error {
+	if state == "" {
+		return writeSupervision(name+".json", nil)
+	}
+	return writeSupervision(name+".json", &Supervision{State: state, Since: time.Now()})
+}
+
+// MarkStopped 记录进程被运维人员停止。已经 unmanage 的进程保持原状态。
+func MarkStopped(name string) error {
+	s, err := GetSupervision(name)
+	if err != nil {
+		return err
+	}
+	if s != nil {
+		return nil
+	}
+	return SetSupervision(name, SupervisionStopped)
+}
+
+// ClearStopped 在进程被再次启动后清除 stop 留下的标记。已经 unmanage 的进程保持原状态。
+func ClearStopped(name string) error {
+	s, err := GetSupervision(name)
+	if err != nil || s == nil || s.State != SupervisionStopped {
+		return err
+	}
+	return SetSupervision(name, "")
+}
+
+// IsSupervised 判断 watch 是否应该守护该进程 (自动拉起、定时运行)。
+// 读取守护状态失败时按正常守护处理，避免服务因状态文件损坏而无人看管。
+func IsSupervised(name string) bool {
+	s, err := GetSupervision(name)
+	return err != nil || s == nil
+}
+
+// GetWatchPaused 返回 watch 是否被暂停，暂停时返回暂停的时间。
+func GetWatchPaused() (bool, time.Time) {
+	s, err := readSupervision(watchPausedFile)
+	if err != nil || s == nil {
+		return false, time.Time{}
+	}
+	return true, s.Since
+}
+
+// SetWatchPaused 暂停或恢复 watch 的守护。暂停期间 watch 不会拉起任何进程，也不运行定时任务。
+func SetWatchPaused(paused bool) error {
+	if !paused {
+		return writeSupervision(watchPausedFile, nil)
+	}
+	return writeSupervision(watchPausedFile, &Supervision{State: "paused", Since: time.Now()})
+}
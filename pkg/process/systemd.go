@@ -0,0 +1,303 @@
+package process
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"procmate/pkg/config"
+)
+
+// SdNotify 按 sd_notify 协议向 systemd 发送状态，例如 "READY=1"、"WATCHDOG=1"、"STATUS=..."。
+// 未在 Type=notify 的服务中运行 (NOTIFY_SOCKET 为空) 时什么都不做。
+func SdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// 以 @ 开头的是 Linux 抽象命名空间中的 socket
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("连接 NOTIFY_SOCKET 失败: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("发送 sd_notify 失败: %w", err)
+	}
+	return nil
+}
+
+// DefaultWatchExitTimeout 是 watch 退出时停止进程的默认总时限，低于 systemd 默认的 TimeoutStopSec (90 秒)
+const DefaultWatchExitTimeout = 80 * time.Second
+
+// SystemdUnitName 返回进程对应的 systemd 单元名。
+func SystemdUnitName(name string) string {
+	return "procmate-" + name + ".service"
+}
+
+// systemdQuote 按 systemd 的规则为 ExecStart= / Environment= 中的一个参数加上引号。
+// % 会被当作说明符展开，需要写成 %%；expandDollar 为 true 时 $ 也会被展开，需要写成 $$。
+func systemdQuote(s string, expandDollar bool) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "%", "%%").Replace(s)
+	if expandDollar {
+		s = strings.ReplaceAll(s, "$", "$$")
+	}
+	return `"` + s + `"`
+}
+
+// systemdLimits 将 limits 配置转换为 systemd 的 Limit*= 和资源控制指令。
+func systemdLimits(limits config.Limits) ([]string, error) {
+	directives := map[string]string{
+		"nofile":  "LimitNOFILE",
+		"nproc":   "LimitNPROC",
+		"core":    "LimitCORE",
+		"memlock": "LimitMEMLOCK",
+		"as":      "LimitAS",
+	}
+	rlimits, err := rlimitArgs(limits)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, l := range rlimits {
+		name, value, _ := strings.Cut(l, "=")
+		if value == unlimited {
+			value = "infinity"
+		}
+		lines = append(lines, directives[name]+"="+value)
+	}
+
+	if limits.MemoryMax != "" {
+		n, err := parseBytes(limits.MemoryMax)
+		if err != nil {
+			return nil, fmt.Errorf("limits.memory_max: %w", err)
+		}
+		value := "infinity"
+		if n >= 0 {
+			value = strconv.FormatInt(n, 10)
+		}
+		lines = append(lines, "MemoryMax="+value)
+	}
+	if limits.CPUMax != "" {
+		v, err := config.ParseCPUMax(limits.CPUMax)
+		if err != nil {
+			return nil, fmt.Errorf("limits.cpu_max: %w", err)
+		}
+		// cpu.max 的格式为 "<quota> <period>"，CPUQuota= 使用百分比
+		fields := strings.Fields(v)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("limits.cpu_max: 无效的取值 '%s'", limits.CPUMax)
+		}
+		if fields[0] != "max" {
+			quota, err1 := strconv.ParseFloat(fields[0], 64)
+			period, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 != nil || err2 != nil || period <= 0 {
+				return nil, fmt.Errorf("limits.cpu_max: 无效的取值 '%s'", limits.CPUMax)
+			}
+			lines = append(lines, fmt.Sprintf("CPUQuota=%g%%", quota/period*100))
+		}
+	}
+	if limits.PidsMax != "" {
+		v, err := parseCount(limits.PidsMax)
+		if err != nil {
+			return nil, fmt.Errorf("limits.pids_max: %w", err)
+		}
+		if v == unlimited {
+			v = "infinity"
+		}
+		lines = append(lines, "TasksMax="+v)
+	}
+	return lines, nil
+}
+
+// GenerateMainUnit 生成以 watch 守护所有进程的 procmate.service。
+//   - Type=notify：watch 完成首次启动后发送 READY=1，每次巡检发送 WATCHDOG=1。
+//   - on_watch_exit 为 leave 时使用 KillMode=process，重启 procmate 不影响受管进程；
+//     否则使用 KillMode=mixed，由 watch 按依赖关系逆序停止进程。
+func GenerateMainUnit(binary, configPath string) string {
+	cfg := config.Current()
+	settings := cfg.Settings
+	interval := settings.WatchIntervalSec
+	if interval <= 0 {
+		interval = 10
+	}
+
+	// 一次巡检最长可能要逐个停止启动超时的进程 (各自最多 stop_timeout)，
+	// 再等待重启的进程逐层就绪，看门狗的时限要留出这段时间
+	var enabled []config.Process
+	maxStart := settings.DefaultStartTimeoutSec
+	stopBudget := 0
+	for _, p := range cfg.Processes {
+		if p.Enabled {
+			enabled = append(enabled, p)
+			maxStart = max(maxStart, p.StartTimeoutSec)
+			if p.Schedule == "" {
+				stop := settings.DefaultStopTimeoutSec
+				if p.StopTimeoutSec > 0 {
+					stop = p.StopTimeoutSec
+				}
+				stopBudget += max(stop, 0)
+			}
+		}
+	}
+	if maxStart <= 0 {
+		maxStart = 60
+	}
+	layers := 1
+	if l, err := GetExecutionLayers(enabled, enabled); err == nil && len(l) > 0 {
+		layers = len(l)
+	}
+	checkBudget := maxStart*layers + stopBudget
+	// watch 会等待 procmate 命令释放进程锁，这些命令同样受启动/停止超时约束，再留出一轮巡检的时间；
+	// watch 不会强制释放端口，端口冲突时直接报错，不需要额外时间
+	watchdog := max(2*interval+2*checkBudget, 60)
+	// 首次巡检逐层启动所有进程后才发送 READY=1，启动时限同样要留出这段时间，且不低于 systemd 默认的 90 秒
+	startTimeout := max(maxStart*layers+30, 90)
+
+	killMode := "process"
+	exitTimeout := DefaultWatchExitTimeout
+	if settings.OnWatchExit != "" && settings.OnWatchExit != "leave" {
+		killMode = "mixed"
+		if settings.WatchExitTimeoutSec > 0 {
+			exitTimeout = time.Duration(settings.WatchExitTimeoutSec) * time.Second
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# 由 procmate systemd generate 生成\n")
+	fmt.Fprintf(&b, "[Unit]\n")
+	fmt.Fprintf(&b, "Description=procmate 进程守护\n")
+	fmt.Fprintf(&b, "Wants=network-online.target\n")
+	fmt.Fprintf(&b, "After=network-online.target\n\n")
+	fmt.Fprintf(&b, "[Service]\n")
+	fmt.Fprintf(&b, "Type=notify\n")
+	fmt.Fprintf(&b, "ExecStart=%s %s %s watch\n", systemdQuote(binary, true), systemdQuote("-f", true), systemdQuote(configPath, true))
+	fmt.Fprintf(&b, "Restart=on-failure\n")
+	fmt.Fprintf(&b, "RestartSec=5s\n")
+	fmt.Fprintf(&b, "TimeoutStartSec=%ds\n", startTimeout)
+	fmt.Fprintf(&b, "WatchdogSec=%ds\n", watchdog)
+	fmt.Fprintf(&b, "KillMode=%s\n", killMode)
+	fmt.Fprintf(&b, "TimeoutStopSec=%ds\n\n", int((exitTimeout+10*time.Second)/time.Second))
+	fmt.Fprintf(&b, "[Install]\n")
+	fmt.Fprintf(&b, "WantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// GenerateProcessUnit 生成由 systemd 直接管理单个进程的单元，依赖关系转换为 After= / Requires=。
+// 运行身份、环境变量和资源限制转换为对应的 systemd 指令，输出写入 journal。
+// systemd 不做 procmate 的就绪检查，依赖方会在本进程启动后立即启动。
+// 未启用的依赖和定时任务依赖会被忽略，定时任务本身不支持生成。
+func GenerateProcessUnit(proc config.Process) (string, error) {
+	cfg := config.Current()
+	if proc.Schedule != "" {
+		return "", fmt.Errorf("'%s' 是定时任务，请使用 watch 或 systemd timer 运行", proc.Name)
+	}
+	argv, err := commandArgv(proc)
+	if err != nil {
+		return "", fmt.Errorf("'%s': %w", proc.Name, err)
+	}
+	limits, err := systemdLimits(proc.Limits)
+	if err != nil {
+		return "", fmt.Errorf("'%s': %w", proc.Name, err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# 由 procmate systemd generate --per-process 生成\n")
+	fmt.Fprintf(&b, "[Unit]\n")
+	fmt.Fprintf(&b, "Description=procmate 进程 %s\n", proc.Name)
+	enabled := make(map[string]bool)
+	scheduled := make(map[string]bool)
+	for _, p := range cfg.Processes {
+		enabled[p.Name] = p.Enabled
+		scheduled[p.Name] = p.Schedule != ""
+	}
+	after := []string{"network-online.target"}
+	var requires []string
+	for _, dep := range proc.DependsOn {
+		// 未启用的进程和定时任务不会生成单元，Requires= 不存在的单元会导致本单元无法启动
+		if !enabled[dep] || scheduled[dep] {
+			continue
+		}
+		after = append(after, SystemdUnitName(dep))
+		requires = append(requires, SystemdUnitName(dep))
+	}
+	fmt.Fprintf(&b, "Wants=network-online.target\n")
+	fmt.Fprintf(&b, "After=%s\n", strings.Join(after, " "))
+	if len(requires) > 0 {
+		fmt.Fprintf(&b, "Requires=%s\n", strings.Join(requires, " "))
+	}
+
+	fmt.Fprintf(&b, "\n[Service]\n")
+	fmt.Fprintf(&b, "Type=simple\n")
+	// systemd 只接受绝对路径或在 PATH 中查找的命令名，相对路径按工作目录展开
+	argv = append([]string(nil), argv...)
+	if strings.Contains(argv[0], "/") && !filepath.IsAbs(argv[0]) {
+		argv[0] = filepath.Join(proc.WorkDir, argv[0])
+		if abs, err := filepath.Abs(argv[0]); err == nil {
+			argv[0] = abs
+		}
+	}
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = systemdQuote(arg, true)
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(quoted, " "))
+	if proc.WorkDir != "" {
+		dir, err := filepath.Abs(proc.WorkDir)
+		if err != nil {
+			return "", fmt.Errorf("'%s': %w", proc.Name, err)
+		}
+		fmt.Fprintf(&b, "WorkingDirectory=%s\n", strings.ReplaceAll(dir, "%", "%%"))
+	}
+
+	keys := make([]string, 0, len(proc.Environment))
+	for k := range proc.Environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(k+"="+proc.Environment[k], false))
+	}
+
+	if proc.User != "" {
+		fmt.Fprintf(&b, "User=%s\n", proc.User)
+	}
+	if proc.RunGroup != "" {
+		fmt.Fprintf(&b, "Group=%s\n", proc.RunGroup)
+	}
+	if len(proc.SupplementaryGroups) > 0 {
+		fmt.Fprintf(&b, "SupplementaryGroups=%s\n", strings.Join(proc.SupplementaryGroups, " "))
+	}
+	if proc.Umask != "" {
+		mask, err := parseUmask(proc.Umask)
+		if err != nil {
+			return "", fmt.Errorf("'%s': %w", proc.Name, err)
+		}
+		fmt.Fprintf(&b, "UMask=%04o\n", mask)
+	}
+	for _, l := range limits {
+		fmt.Fprintf(&b, "%s\n", l)
+	}
+
+	interval := cfg.Settings.WatchIntervalSec
+	if interval <= 0 {
+		interval = 10
+	}
+	fmt.Fprintf(&b, "Restart=always\n")
+	fmt.Fprintf(&b, "RestartSec=%ds\n", interval)
+	if timeout := stopTimeout(proc); timeout > 0 {
+		fmt.Fprintf(&b, "TimeoutStopSec=%ds\n", int(timeout/time.Second))
+	}
+
+	fmt.Fprintf(&b, "\n[Install]\n")
+	fmt.Fprintf(&b, "WantedBy=multi-user.target\n")
+	return b.String(), nil
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+
+package process
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSdNotify(t *testing.T) {
+	// macOS 的 socket 路径最长 104 字节，t.TempDir() 可能超出，使用较短的临时目录
+	dir, err := os.MkdirTemp("", "sdnotify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("监听 %s 失败: %v", socket, err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", socket)
+
+	for _, state := range []string{"READY=1", "WATCHDOG=1", "STATUS=3/3 个进程正常运行"} {
+		if err := SdNotify(state); err != nil {
+			t.Fatalf("SdNotify(%q) 失败: %v", state, err)
+		}
+		buf := make([]byte, 4096)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("读取 %q 失败: %v", state, err)
+		}
+		if got := string(buf[:n]); got != state {
+			t.Errorf("收到 %q，期望 %q", got, state)
+		}
+	}
+}
+
+func TestSdNotifyWithoutSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := SdNotify("READY=1"); err != nil {
+		t.Errorf("未设置 NOTIFY_SOCKET 时应忽略通知，得到错误: %v", err)
+	}
+}